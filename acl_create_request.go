@@ -47,6 +47,10 @@ func (c *CreateAclsRequest) version() int16 {
 	return c.Version
 }
 
+func (c *CreateAclsRequest) setVersion(v int16) {
+	c.Version = v
+}
+
 func (c *CreateAclsRequest) headerVersion() int16 {
 	return 1
 }
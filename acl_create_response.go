@@ -56,6 +56,10 @@ func (c *CreateAclsResponse) version() int16 {
 	return c.Version
 }
 
+func (c *CreateAclsResponse) setVersion(v int16) {
+	c.Version = v
+}
+
 func (c *CreateAclsResponse) headerVersion() int16 {
 	return 0
 }
@@ -56,6 +56,10 @@ func (d *DeleteAclsResponse) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteAclsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteAclsResponse) headerVersion() int16 {
 	return 0
 }
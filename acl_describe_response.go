@@ -77,6 +77,10 @@ func (d *DescribeAclsResponse) version() int16 {
 	return d.Version
 }
 
+func (d *DescribeAclsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DescribeAclsResponse) headerVersion() int16 {
 	return 0
 }
@@ -49,6 +49,10 @@ func (a *AddOffsetsToTxnRequest) version() int16 {
 	return a.Version
 }
 
+func (a *AddOffsetsToTxnRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AddOffsetsToTxnRequest) headerVersion() int16 {
 	return 1
 }
@@ -41,6 +41,10 @@ func (a *AddOffsetsToTxnResponse) version() int16 {
 	return a.Version
 }
 
+func (a *AddOffsetsToTxnResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AddOffsetsToTxnResponse) headerVersion() int16 {
 	return 0
 }
@@ -73,6 +73,10 @@ func (a *AddPartitionsToTxnRequest) version() int16 {
 	return a.Version
 }
 
+func (a *AddPartitionsToTxnRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AddPartitionsToTxnRequest) headerVersion() int16 {
 	return 1
 }
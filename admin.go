@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"strconv"
 	"sync"
 	"time"
@@ -46,6 +47,13 @@ type ClusterAdmin interface {
 	// This operation is supported by brokers with version 2.4.0.0 or higher.
 	AlterPartitionReassignments(topic string, assignment [][]int32) error
 
+	// Elect leaders for the given partitions, or for all partitions when nil.
+	// The result of each partition is returned, ErrElectionNotNeeded means its
+	// leader is already the one that would be elected. Preferred elections are
+	// supported by brokers with version 2.2.0.0 or higher, unclean elections by
+	// brokers with version 2.4.0.0 or higher.
+	ElectLeaders(electionType ElectionType, partitions map[string][]int32) (map[string]map[int32]*PartitionResult, error)
+
 	// Provides info on ongoing partitions replica reassignments.
 	// This operation is supported by brokers with version 2.4.0.0 or higher.
 	ListPartitionReassignments(topics string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error)
@@ -114,7 +122,8 @@ type ClusterAdmin interface {
 	// Delete a consumer group.
 	DeleteConsumerGroup(group string) error
 
-	// Get information about the nodes in the cluster
+	// Get information about the nodes in the cluster. Uses a DescribeClusterRequest
+	// on Kafka 2.8+ and falls back to a MetadataRequest when it is not supported.
 	DescribeCluster() (brokers []*Broker, controllerID int32, err error)
 
 	// Get information about all log directories on the given set of brokers
@@ -297,6 +306,22 @@ func (ca *clusterAdmin) DescribeTopics(topics []string) (metadata []*TopicMetada
 }
 
 func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32, err error) {
+	if ca.conf.Version.IsAtLeast(V2_8_0_0) {
+		controller, err := ca.Controller()
+		if err != nil {
+			return nil, int32(0), err
+		}
+		// a broker closes the connection on requests for APIs it does not
+		// implement, so only send DescribeCluster to brokers advertising it
+		if controller.advertisedApiVersions(new(DescribeClusterRequest).key()) != nil {
+			brokers, controllerID, err = ca.describeCluster(controller)
+			if !errors.Is(err, ErrUnsupportedVersion) {
+				return brokers, controllerID, err
+			}
+		}
+		DebugLogger.Println("DescribeCluster is not supported by the broker, falling back to metadata")
+	}
+
 	var response *MetadataResponse
 	err = ca.retryOnError(isErrNotController, func() error {
 		controller, err := ca.Controller()
@@ -315,7 +340,44 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 		return nil, int32(0), err
 	}
 
-	return response.Brokers, response.ControllerID, nil
+	brokers = make([]*Broker, 0, len(response.Brokers))
+	for _, broker := range response.Brokers {
+		brokers = append(brokers, ca.resolveBroker(broker))
+	}
+	return brokers, response.ControllerID, nil
+}
+
+func (ca *clusterAdmin) describeCluster(b *Broker) ([]*Broker, int32, error) {
+	response, err := b.DescribeCluster(&DescribeClusterRequest{})
+	if err != nil {
+		return nil, int32(0), err
+	}
+	if !errors.Is(response.ErrorCode, ErrNoError) {
+		if response.ErrorMessage != nil && *response.ErrorMessage != "" {
+			return nil, int32(0), Wrap(response.ErrorCode, errors.New(*response.ErrorMessage))
+		}
+		return nil, int32(0), response.ErrorCode
+	}
+
+	brokers := make([]*Broker, 0, len(response.Brokers))
+	for _, described := range response.Brokers {
+		broker := NewBroker(net.JoinHostPort(described.Host, strconv.Itoa(int(described.Port))))
+		broker.id = described.BrokerID
+		broker.rack = described.Rack
+		brokers = append(brokers, ca.resolveBroker(broker))
+	}
+	return brokers, response.ControllerID, nil
+}
+
+// resolveBroker maps the advertised address of a broker described by the
+// cluster like the client does, and returns the broker the client registered
+// under the same ID and address if there is one.
+func (ca *clusterAdmin) resolveBroker(broker *Broker) *Broker {
+	mapBrokerAddress(ca.conf, broker)
+	if registered, err := ca.findBroker(broker.ID()); err == nil && registered.Addr() == broker.Addr() {
+		return registered
+	}
+	return broker
 }
 
 func (ca *clusterAdmin) findBroker(id int32) (*Broker, error) {
@@ -557,6 +619,43 @@ func (ca *clusterAdmin) AlterPartitionReassignments(topic string, assignment [][
 	})
 }
 
+func (ca *clusterAdmin) ElectLeaders(electionType ElectionType, partitions map[string][]int32) (map[string]map[int32]*PartitionResult, error) {
+	request := &ElectLeadersRequest{
+		Type:            electionType,
+		TopicPartitions: partitions,
+		TimeoutMs:       int32(60000),
+	}
+
+	if ca.conf.Version.IsAtLeast(V2_4_0_0) {
+		request.Version = 2
+	} else if electionType != PreferredElection {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var rsp *ElectLeadersResponse
+	err := ca.retryOnError(isErrNotController, func() error {
+		b, err := ca.Controller()
+		if err != nil {
+			return err
+		}
+		_ = b.Open(ca.client.Config())
+
+		rsp, err = b.ElectLeaders(request)
+		if err == nil && !errors.Is(rsp.ErrorCode, ErrNoError) {
+			err = rsp.ErrorCode
+		}
+		if isErrNotController(err) {
+			_, _ = ca.refreshController()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.ReplicaElectionResults, nil
+}
+
 func (ca *clusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (topicStatus map[string]map[int32]*PartitionReplicaReassignmentsStatus, err error) {
 	if topic == "" {
 		return nil, ErrInvalidTopic
@@ -655,6 +754,22 @@ func dependsOnSpecificNode(resource ConfigResource) bool {
 		resource.Type == BrokerLoggerResource
 }
 
+// configResourceError converts the error code and message of a config
+// resource response into an error, keeping the KError available to errors.Is
+// while surfacing the broker's explanation of what went wrong
+func configResourceError(errorCode int16, errorMsg string) error {
+	if errorCode != 0 {
+		if errorMsg != "" {
+			return Wrap(KError(errorCode), errors.New(errorMsg))
+		}
+		return KError(errorCode)
+	}
+	if errorMsg != "" {
+		return errors.New(errorMsg)
+	}
+	return nil
+}
+
 func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]ConfigEntry, error) {
 	var entries []ConfigEntry
 	var resources []*ConfigResource
@@ -700,11 +815,8 @@ func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]ConfigEntry,
 
 	for _, rspResource := range rsp.Resources {
 		if rspResource.Name == resource.Name {
-			if rspResource.ErrorMsg != "" {
-				return nil, errors.New(rspResource.ErrorMsg)
-			}
-			if rspResource.ErrorCode != 0 {
-				return nil, KError(rspResource.ErrorCode)
+			if err := configResourceError(rspResource.ErrorCode, rspResource.ErrorMsg); err != nil {
+				return nil, err
 			}
 			for _, cfgEntry := range rspResource.Configs {
 				entries = append(entries, *cfgEntry)
@@ -758,11 +870,8 @@ func (ca *clusterAdmin) AlterConfig(resourceType ConfigResourceType, name string
 
 	for _, rspResource := range rsp.Resources {
 		if rspResource.Name == name {
-			if rspResource.ErrorMsg != "" {
-				return errors.New(rspResource.ErrorMsg)
-			}
-			if rspResource.ErrorCode != 0 {
-				return KError(rspResource.ErrorCode)
+			if err := configResourceError(rspResource.ErrorCode, rspResource.ErrorMsg); err != nil {
+				return err
 			}
 		}
 	}
@@ -810,11 +919,8 @@ func (ca *clusterAdmin) IncrementalAlterConfig(resourceType ConfigResourceType,
 
 	for _, rspResource := range rsp.Resources {
 		if rspResource.Name == name {
-			if rspResource.ErrorMsg != "" {
-				return errors.New(rspResource.ErrorMsg)
-			}
-			if rspResource.ErrorCode != 0 {
-				return KError(rspResource.ErrorCode)
+			if err := configResourceError(rspResource.ErrorCode, rspResource.ErrorMsg); err != nil {
+				return err
 			}
 		}
 	}
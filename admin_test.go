@@ -2,6 +2,8 @@ package sarama
 
 import (
 	"errors"
+	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -444,6 +446,74 @@ func TestClusterAdminAlterPartitionReassignmentsWithDiffVersion(t *testing.T) {
 	}
 }
 
+func TestClusterAdminElectLeaders(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	secondBroker := NewMockBroker(t, 2)
+	defer secondBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(secondBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(secondBroker.Addr(), secondBroker.BrokerID()),
+	})
+
+	secondBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest":  NewMockApiVersionsResponse(t),
+		"ElectLeadersRequest": NewMockElectLeadersResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_4_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	results, err := admin.ElectLeaders(UncleanElection, map[string][]int32{"my_topic": {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, partition := range []int32{0, 1} {
+		if result := results["my_topic"][partition]; result == nil || !errors.Is(result.ErrorCode, ErrNoError) {
+			t.Errorf("unexpected result for partition %d: %+v", partition, result)
+		}
+	}
+
+	for _, rr := range secondBroker.History() {
+		if req, ok := rr.Request.(*ElectLeadersRequest); ok && (req.Version != 2 || req.Type != UncleanElection) {
+			t.Errorf("expected a v2 unclean election request, got v%d type %d", req.Version, req.Type)
+		}
+	}
+}
+
+func TestClusterAdminElectLeadersUncleanUnsupported(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_2_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	if _, err := admin.ElectLeaders(UncleanElection, nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
 func TestClusterAdminListPartitionReassignments(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -822,6 +892,45 @@ func TestClusterAdminDescribeConfigWithErrorCode(t *testing.T) {
 	}
 }
 
+func TestClusterAdminDescribeConfigWithErrorMessage(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	errMsg := "Topic authorization failed for my_topic"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeConfigsRequest": NewMockWrapper(&DescribeConfigsResponse{
+			Version: 1,
+			Resources: []*ResourceResponse{{
+				Name:      "my_topic",
+				Type:      TopicResource,
+				ErrorCode: int16(ErrTopicAuthorizationFailed),
+				ErrorMsg:  errMsg,
+			}},
+		}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_1_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = admin.Close()
+	}()
+
+	_, err = admin.DescribeConfig(ConfigResource{Name: "my_topic", Type: TopicResource})
+	if !errors.Is(err, ErrTopicAuthorizationFailed) {
+		t.Fatalf("expected ErrTopicAuthorizationFailed, got %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), errMsg) {
+		t.Fatalf("expected error message %q to be surfaced, got %v", errMsg, err)
+	}
+}
+
 // TestClusterAdminDescribeBrokerConfig ensures that a describe broker config
 // is sent to the broker in the resource struct, _not_ the controller
 func TestClusterAdminDescribeBrokerConfig(t *testing.T) {
@@ -1734,6 +1843,117 @@ func TestRefreshMetaDataWithDifferentController(t *testing.T) {
 	}
 }
 
+func TestClusterAdminDescribeCluster(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedHost, seedPort, err := net.SplitHostPort(seedBroker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(seedPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rack := "rack1"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 60, MinVersion: 0, MaxVersion: 0},
+		}),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeClusterRequest": NewMockWrapper(&DescribeClusterResponse{
+			ClusterID:    "cluster",
+			ControllerID: 3,
+			Brokers: []*DescribeClusterBroker{
+				{BrokerID: 1, Host: seedHost, Port: int32(port)},
+				{BrokerID: 3, Host: "kafka-3", Port: 9092, Rack: &rack},
+				{BrokerID: 4, Host: "kafka-4", Port: 9093},
+			},
+		}),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+	config.Net.AddressMapper = func(advertised string) string {
+		if advertised == "kafka-4:9093" {
+			return "10.0.0.4:9093"
+		}
+		return advertised
+	}
+
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := NewClusterAdminFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	brokers, controllerID, err := admin.DescribeCluster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if controllerID != 3 {
+		t.Errorf("expected controller 3, got %d", controllerID)
+	}
+	if len(brokers) != 3 {
+		t.Fatalf("expected 3 brokers, got %d", len(brokers))
+	}
+	if registered, err := client.Broker(1); err != nil || brokers[0] != registered {
+		t.Errorf("expected the broker registered by the client for broker 1, got %v", brokers[0])
+	}
+	if brokers[1].ID() != 3 || brokers[1].Addr() != "kafka-3:9092" || brokers[1].Rack() != rack {
+		t.Errorf("unexpected second broker %d %s %s", brokers[1].ID(), brokers[1].Addr(), brokers[1].Rack())
+	}
+	if brokers[2].ID() != 4 || brokers[2].Addr() != "10.0.0.4:9093" || brokers[2].Rack() != "" {
+		t.Errorf("unexpected third broker %d %s %s", brokers[2].ID(), brokers[2].Addr(), brokers[2].Rack())
+	}
+}
+
+func TestClusterAdminDescribeClusterFallsBackToMetadata(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	// the broker does not advertise DescribeCluster, a real one would close
+	// the connection when sent the request
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_8_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	brokers, controllerID, err := admin.DescribeCluster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if controllerID != seedBroker.BrokerID() {
+		t.Errorf("expected controller %d, got %d", seedBroker.BrokerID(), controllerID)
+	}
+	if len(brokers) != 1 || brokers[0].Addr() != seedBroker.Addr() {
+		t.Errorf("expected the brokers from metadata, got %v", brokers)
+	}
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*DescribeClusterRequest); ok {
+			t.Error("expected no DescribeClusterRequest to a broker not advertising it")
+		}
+	}
+}
+
 func TestDescribeLogDirs(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
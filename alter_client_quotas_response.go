@@ -137,6 +137,10 @@ func (a *AlterClientQuotasResponse) version() int16 {
 	return a.Version
 }
 
+func (a *AlterClientQuotasResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AlterClientQuotasResponse) headerVersion() int16 {
 	return 0
 }
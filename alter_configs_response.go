@@ -108,6 +108,10 @@ func (a *AlterConfigsResponse) version() int16 {
 	return a.Version
 }
 
+func (a *AlterConfigsResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *AlterConfigsResponse) headerVersion() int16 {
 	return 0
 }
@@ -109,6 +109,10 @@ func (r *AlterPartitionReassignmentsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *AlterPartitionReassignmentsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *AlterPartitionReassignmentsRequest) headerVersion() int16 {
 	return 2
 }
@@ -133,6 +133,10 @@ func (r *AlterUserScramCredentialsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *AlterUserScramCredentialsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *AlterUserScramCredentialsRequest) headerVersion() int16 {
 	return 2
 }
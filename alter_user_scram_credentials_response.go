@@ -85,6 +85,10 @@ func (r *AlterUserScramCredentialsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *AlterUserScramCredentialsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *AlterUserScramCredentialsResponse) headerVersion() int16 {
 	return 2
 }
@@ -50,6 +50,10 @@ func (r *ApiVersionsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ApiVersionsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ApiVersionsRequest) headerVersion() int16 {
 	if r.Version >= 3 {
 		return 2
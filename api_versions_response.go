@@ -96,6 +96,12 @@ func (r *ApiVersionsResponse) decode(pd packetDecoder, version int16) (err error
 		return err
 	}
 
+	// Brokers answer an ApiVersionsRequest version they do not understand
+	// with a v0 response listing the versions they do support (KIP-511).
+	if r.Version >= 3 && KError(r.ErrorCode) == ErrUnsupportedVersion {
+		r.Version = 0
+	}
+
 	var numApiKeys int
 	if r.Version >= 3 {
 		numApiKeys, err = pd.getCompactArrayLength()
@@ -140,6 +146,10 @@ func (r *ApiVersionsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *ApiVersionsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ApiVersionsResponse) headerVersion() int16 {
 	// ApiVersionsResponse always includes a v0 header.
 	// See KIP-511 for details
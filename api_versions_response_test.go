@@ -57,3 +57,26 @@ func TestApiVersionsResponseV3(t *testing.T) {
 		t.Error("Decoding error: expected 0x01 but got", response.ApiKeys[0].MaxVersion)
 	}
 }
+
+func TestApiVersionsResponseV3UnsupportedVersion(t *testing.T) {
+	// a broker without v3 support answers with a v0 encoded response
+	unsupported := []byte{
+		0x00, 0x23, // ErrUnsupportedVersion
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x12,
+		0x00, 0x00,
+		0x00, 0x02,
+	}
+
+	response := new(ApiVersionsResponse)
+	testVersionDecodable(t, "unsupported version", response, unsupported, 3)
+	if KError(response.ErrorCode) != ErrUnsupportedVersion {
+		t.Error("Decoding error: expected ErrUnsupportedVersion but got", response.ErrorCode)
+	}
+	if response.Version != 0 {
+		t.Error("Decoding error: expected fallback to version 0 but got", response.Version)
+	}
+	if len(response.ApiKeys) != 1 || response.ApiKeys[0].ApiKey != 18 || response.ApiKeys[0].MaxVersion != 2 {
+		t.Error("Decoding error: unexpected api keys", response.ApiKeys)
+	}
+}
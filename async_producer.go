@@ -46,7 +46,9 @@ type AsyncProducer interface {
 	// Errors is the error output channel back to the user. You MUST read from this
 	// channel or the Producer will deadlock when the channel is full. Alternatively,
 	// you can set Producer.Return.Errors in your config to false, which prevents
-	// errors to be returned.
+	// errors to be returned. Retriable errors are retried internally, up to
+	// Producer.Retry.Max times, so only messages which could not be delivered
+	// are returned here.
 	Errors() <-chan *ProducerError
 
 	// IsTransactional return true when current producer is transactional.
@@ -192,6 +194,7 @@ type ProducerMessage struct {
 	Timestamp time.Time
 
 	retries        int
+	moved          bool // retried on another partition than the one it failed on
 	flags          flagSet
 	expectation    chan *ProducerError
 	sequenceNumber int32
@@ -223,6 +226,7 @@ func (m *ProducerMessage) ByteSize(version int) int {
 func (m *ProducerMessage) clear() {
 	m.flags = 0
 	m.retries = 0
+	m.moved = false
 	m.sequenceNumber = 0
 	m.producerEpoch = 0
 	m.hasSequence = false
@@ -252,6 +256,16 @@ func (pe ProducerErrors) Error() string {
 	return fmt.Sprintf("kafka: Failed to deliver %d messages.", len(pe))
 }
 
+// ProducerAcknowledgement describes a batch of messages written to a single
+// partition, as acknowledged by a produce response. It is passed to the
+// Producer.OnAcknowledgement callback.
+type ProducerAcknowledgement struct {
+	Topic     string
+	Partition int32
+	Offset    int64 // offset of the first message of the batch
+	Count     int   // number of messages acknowledged
+}
+
 func (p *asyncProducer) IsTransactional() bool {
 	return p.txnmgr.isTransactional()
 }
@@ -503,6 +517,8 @@ func (tp *topicProducer) dispatch() {
 				tp.parent.returnError(msg, err)
 				continue
 			}
+		} else if msg.flags == 0 {
+			tp.repartitionMessage(msg)
 		}
 
 		handler := tp.handlers[msg.Partition]
@@ -521,15 +537,9 @@ func (tp *topicProducer) dispatch() {
 
 func (tp *topicProducer) partitionMessage(msg *ProducerMessage) error {
 	var partitions []int32
+	requiresConsistency := tp.requiresConsistency(msg)
 
 	err := tp.breaker.Run(func() (err error) {
-		requiresConsistency := false
-		if ep, ok := tp.partitioner.(DynamicConsistencyPartitioner); ok {
-			requiresConsistency = ep.MessageRequiresConsistency(msg)
-		} else {
-			requiresConsistency = tp.partitioner.RequiresConsistency()
-		}
-
 		if requiresConsistency {
 			partitions, err = tp.parent.client.Partitions(msg.Topic)
 		} else {
@@ -547,19 +557,98 @@ func (tp *topicProducer) partitionMessage(msg *ProducerMessage) error {
 		return ErrLeaderNotAvailable
 	}
 
-	choice, err := tp.partitioner.Partition(msg, numPartitions)
-
-	if err != nil {
-		return err
-	} else if choice < 0 || choice >= numPartitions {
-		return ErrInvalidPartition
+	// with a single partition there is nothing to choose, so the built-in
+	// partitioners are skipped. Manual and custom partitioners are still asked
+	// since they may validate or reject the message.
+	var choice int32
+	if numPartitions > 1 || !isBuiltinAutoPartitioner(tp.partitioner) {
+		choice, err = tp.partitioner.Partition(msg, numPartitions)
+		if err != nil {
+			return err
+		} else if choice < 0 || choice >= numPartitions {
+			return ErrInvalidPartition
+		}
 	}
 
 	msg.Partition = partitions[choice]
 
+	if requiresConsistency && tp.parent.conf.Producer.FailFastOnOfflinePartition {
+		writable, err := tp.parent.client.WritablePartitions(msg.Topic)
+		if err != nil {
+			return err
+		}
+		online := false
+		for _, partition := range writable {
+			if partition == msg.Partition {
+				online = true
+				break
+			}
+		}
+		if !online {
+			return ErrLeaderNotAvailable
+		}
+	}
+
 	return nil
 }
 
+func (tp *topicProducer) requiresConsistency(msg *ProducerMessage) bool {
+	if ep, ok := tp.partitioner.(DynamicConsistencyPartitioner); ok {
+		return ep.MessageRequiresConsistency(msg)
+	}
+	return tp.partitioner.RequiresConsistency()
+}
+
+// repartitionMessage moves a retried message away from the partition it failed
+// on, to a writable partition led by another broker, since retrying against a
+// partition whose leader is down is unlikely to succeed. Only partitioners which
+// never require consistency move messages, a DynamicConsistencyPartitioner such
+// as the hash partitioner keeps even its keyless messages where they were, as
+// do idempotent producers (whose sequence numbers are per partition). If no
+// such partition exists in the cached metadata the message is retried where it
+// was.
+func (tp *topicProducer) repartitionMessage(msg *ProducerMessage) {
+	msg.moved = false
+	if tp.parent.conf.Producer.Idempotent || tp.partitioner.RequiresConsistency() {
+		return
+	}
+
+	// only the cached metadata is used, as refreshing it here would hold up
+	// every message of the topic
+	metadata, ok := tp.parent.client.(cachedMetadataProvider)
+	if !ok {
+		return
+	}
+	writable := metadata.cachedWritablePartitions(msg.Topic)
+	failed, failedKnown := metadata.cachedLeaderID(msg.Topic, msg.Partition)
+	partitions := make([]int32, 0, len(writable))
+	for _, partition := range writable {
+		if partition == msg.Partition {
+			continue
+		}
+		if failedKnown {
+			if leader, ok := metadata.cachedLeaderID(msg.Topic, partition); !ok || leader == failed {
+				continue
+			}
+		}
+		partitions = append(partitions, partition)
+	}
+	numPartitions := int32(len(partitions))
+	if numPartitions == 0 {
+		return
+	}
+
+	choice, err := tp.partitioner.Partition(msg, numPartitions)
+	if err != nil || choice < 0 || choice >= numPartitions {
+		return
+	}
+
+	DebugLogger.Printf("producer/partitioner/%s moving retried message from partition %d to %d\n",
+		msg.Topic, msg.Partition, partitions[choice])
+	msg.Partition = partitions[choice]
+	msg.moved = true
+}
+
 // one per partition per topic
 // dispatches messages to the appropriate broker
 // also responsible for maintaining message order during retries
@@ -656,7 +745,9 @@ func (pp *partitionProducer) dispatch() {
 			}
 		}
 
-		if msg.retries > pp.highWatermark {
+		// a message moved here from another partition is not ordered against
+		// the messages of this one, so it does not hold them up
+		if msg.retries > pp.highWatermark && !msg.moved {
 			if err := pp.updateLeaderIfBrokerProducerIsNil(msg); err != nil {
 				continue
 			}
@@ -1084,9 +1175,9 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			return
 		}
 
-		switch block.Err {
+		switch {
 		// Success
-		case ErrNoError:
+		case block.Err == ErrNoError:
 			if bp.parent.conf.Version.IsAtLeast(V0_10_0_0) && !block.Timestamp.IsZero() {
 				for _, msg := range pSet.msgs {
 					msg.Timestamp = block.Timestamp
@@ -1095,13 +1186,20 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			for i, msg := range pSet.msgs {
 				msg.Offset = block.Offset + int64(i)
 			}
+			if onAck := bp.parent.conf.Producer.OnAcknowledgement; onAck != nil {
+				onAck(ProducerAcknowledgement{
+					Topic:     topic,
+					Partition: partition,
+					Offset:    block.Offset,
+					Count:     len(pSet.msgs),
+				})
+			}
 			bp.parent.returnSuccesses(pSet.msgs)
 		// Duplicate
-		case ErrDuplicateSequenceNumber:
+		case block.Err == ErrDuplicateSequenceNumber:
 			bp.parent.returnSuccesses(pSet.msgs)
 		// Retriable errors
-		case ErrInvalidMessage, ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeaderForPartition,
-			ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
+		case block.Err.Retriable():
 			if bp.parent.conf.Producer.Retry.Max <= 0 {
 				bp.parent.abandonBrokerConnection(bp.broker)
 				bp.parent.returnErrors(pSet.msgs, block.Err)
@@ -1113,7 +1211,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			if bp.parent.conf.Producer.Retry.Max <= 0 {
 				bp.parent.abandonBrokerConnection(bp.broker)
 			}
-			bp.parent.returnErrors(pSet.msgs, block.Err)
+			bp.parent.returnErrors(pSet.msgs, authorizationError(block.Err, topic))
 		}
 	})
 
@@ -1127,28 +1225,24 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 
 		sent.eachPartition(func(topic string, partition int32, pSet *partitionSet) {
 			block := response.GetBlock(topic, partition)
-			if block == nil {
+			if block == nil || !block.Err.Retriable() {
 				// handled in the previous "eachPartition" loop
 				return
 			}
 
-			switch block.Err {
-			case ErrInvalidMessage, ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeaderForPartition,
-				ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
-				Logger.Printf("producer/broker/%d state change to [retrying] on %s/%d because %v\n",
-					bp.broker.ID(), topic, partition, block.Err)
-				if bp.currentRetries[topic] == nil {
-					bp.currentRetries[topic] = make(map[int32]error)
-				}
-				bp.currentRetries[topic][partition] = block.Err
-				if bp.parent.conf.Producer.Idempotent {
-					go bp.parent.retryBatch(topic, partition, pSet, block.Err)
-				} else {
-					bp.parent.retryMessages(pSet.msgs, block.Err)
-				}
-				// dropping the following messages has the side effect of incrementing their retry count
-				bp.parent.retryMessages(bp.buffer.dropPartition(topic, partition), block.Err)
+			Logger.Printf("producer/broker/%d state change to [retrying] on %s/%d because %v\n",
+				bp.broker.ID(), topic, partition, block.Err)
+			if bp.currentRetries[topic] == nil {
+				bp.currentRetries[topic] = make(map[int32]error)
+			}
+			bp.currentRetries[topic][partition] = block.Err
+			if bp.parent.conf.Producer.Idempotent {
+				go bp.parent.retryBatch(topic, partition, pSet, block.Err)
+			} else {
+				bp.parent.retryMessages(pSet.msgs, block.Err)
 			}
+			// dropping the following messages has the side effect of incrementing their retry count
+			bp.parent.retryMessages(bp.buffer.dropPartition(topic, partition), block.Err)
 		})
 	}
 }
@@ -1334,6 +1428,8 @@ func (p *asyncProducer) retryMessages(batch []*ProducerMessage, err error) {
 }
 
 func (p *asyncProducer) getBrokerProducer(broker *Broker) *brokerProducer {
+	broker = p.produceBroker(broker)
+
 	p.brokerLock.Lock()
 	defer p.brokerLock.Unlock()
 
@@ -1359,12 +1455,23 @@ func (p *asyncProducer) unrefBrokerProducer(broker *Broker, bp *brokerProducer)
 		close(bp.input)
 		delete(p.brokerRefs, bp)
 
-		if p.brokers[broker] == bp {
-			delete(p.brokers, broker)
+		// the broker producer is registered under the connection it uses,
+		// which differs from the leader with Net.SeparateConnections
+		if p.brokers[bp.broker] == bp {
+			delete(p.brokers, bp.broker)
 		}
 	}
 }
 
+// produceBroker returns the connection to the leader used for producing,
+// which is a dedicated one with Net.SeparateConnections.
+func (p *asyncProducer) produceBroker(leader *Broker) *Broker {
+	if provider, ok := p.client.(dedicatedBrokerProvider); ok {
+		return provider.dedicatedBroker(leader, produceConnection)
+	}
+	return leader
+}
+
 func (p *asyncProducer) abandonBrokerConnection(broker *Broker) {
 	p.brokerLock.Lock()
 	defer p.brokerLock.Unlock()
@@ -906,6 +906,47 @@ func TestAsyncProducerOutOfRetries(t *testing.T) {
 	safeClose(t, producer)
 }
 
+func TestAsyncProducerRetriesRetriableErrors(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 1
+	config.Producer.Retry.Backoff = 0
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// any Retriable error is retried, not only the usual leadership ones
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Key: nil, Value: StringEncoder(TestMessage)}
+	prodNetworkError := new(ProduceResponse)
+	prodNetworkError.AddTopicPartition("my_topic", 0, ErrNetworkException)
+	leader.Returns(prodNetworkError)
+	leader.Returns(metadataResponse)
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+	expectResults(t, producer, 1, 0)
+
+	// the others are not
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Key: nil, Value: StringEncoder(TestMessage)}
+	prodTooLarge := new(ProduceResponse)
+	prodTooLarge.AddTopicPartition("my_topic", 0, ErrMessageSizeTooLarge)
+	leader.Returns(prodTooLarge)
+	expectResults(t, producer, 0, 1)
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
 func TestAsyncProducerRetryWithReferenceOpen(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader := NewMockBroker(t, 2)
@@ -2334,3 +2375,526 @@ ProducerLoop:
 
 	log.Printf("Successfully produced: %d; errors: %d\n", successes, producerErrors)
 }
+
+// This example shows how to configure the producer to accumulate messages
+// into per-partition batches, flushed as soon as a batch reaches a byte size
+// or a message count, or once a time interval has elapsed.
+func ExampleAsyncProducer_batching() {
+	config := NewTestConfig()
+	config.Producer.Flush.Bytes = 1 << 20
+	config.Producer.Flush.Messages = 1000
+	config.Producer.Flush.Frequency = 100 * time.Millisecond
+	producer, err := NewAsyncProducer([]string{"localhost:9092"}, config)
+	if err != nil {
+		panic(err)
+	}
+
+	go func() {
+		for err := range producer.Errors() {
+			log.Println("Failed to produce message", err)
+		}
+	}()
+
+	for i := 0; i < 10000; i++ {
+		producer.Input() <- &ProducerMessage{
+			Topic: "my_topic",
+			Key:   StringEncoder(strconv.Itoa(i % 16)),
+			Value: StringEncoder("testing 123"),
+		}
+	}
+
+	// Close flushes the batches which are still being accumulated.
+	if err := producer.Close(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func TestAsyncProducerSkipsOfflinePartitions(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, -1, nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 2, -1, nil, nil, nil, ErrLeaderNotAvailable)
+	// leaderless partitions make the client retry the metadata request, so
+	// both brokers keep answering it
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 0, ErrNoError),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case msg := <-producer.Errors():
+			t.Error(msg.Err)
+		case msg := <-producer.Successes():
+			if msg.Partition != 0 {
+				t.Errorf("expected keyless message to be routed to partition 0, got %d", msg.Partition)
+			}
+		}
+	}
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerFailFastOnOfflinePartition(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, -1, nil, nil, nil, ErrLeaderNotAvailable)
+	// leaderless partitions make the client retry the metadata request, so
+	// both brokers keep answering it
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 0, ErrNoError),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = NewManualPartitioner
+	config.Producer.FailFastOnOfflinePartition = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Partition: 1, Value: StringEncoder(TestMessage)}
+	select {
+	case msg := <-producer.Errors():
+		if !errors.Is(msg.Err, ErrLeaderNotAvailable) {
+			t.Errorf("expected ErrLeaderNotAvailable, got %v", msg.Err)
+		}
+	case <-producer.Successes():
+		t.Error("expected message to an offline partition to fail")
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Partition: 0, Value: StringEncoder(TestMessage)}
+	expectResults(t, producer, 1, 0)
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerSinglePartitionTopic(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = NewHashPartitioner
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Key: StringEncoder(strconv.Itoa(i)), Value: StringEncoder(TestMessage)}
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case msg := <-producer.Successes():
+			if msg.Partition != 0 {
+				t.Errorf("expected message with key %v to go to partition 0, got %d", msg.Key, msg.Partition)
+			}
+		case msg := <-producer.Errors():
+			t.Fatal(msg.Err)
+		}
+	}
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerSinglePartitionTopicManualPartition(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = NewManualPartitioner
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an explicit partition is still validated rather than rewritten to 0
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Partition: 3, Value: StringEncoder(TestMessage)}
+	select {
+	case msg := <-producer.Errors():
+		if !errors.Is(msg.Err, ErrInvalidPartition) {
+			t.Errorf("expected ErrInvalidPartition, got %v", msg.Err)
+		}
+	case msg := <-producer.Successes():
+		t.Errorf("expected message to partition 3 to fail, was sent to partition %d", msg.Partition)
+	}
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerRetryRepartitionsKeylessMessage(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader0 := NewMockBroker(t, 2)
+	leader1 := NewMockBroker(t, 3)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader0.Addr(), leader0.BrokerID())
+	metadataResponse.AddBroker(leader1.Addr(), leader1.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader0.BrokerID(), nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, leader1.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+	})
+
+	// partition 0 keeps failing, partition 1 is healthy
+	leader0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 0, ErrNotLeaderForPartition),
+	})
+	leader1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 1, ErrNoError),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 1
+	config.Producer.Partitioner = NewRoundRobinPartitioner
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	select {
+	case msg := <-producer.Errors():
+		t.Error(msg.Err)
+	case msg := <-producer.Successes():
+		if msg.Partition != 1 {
+			t.Errorf("expected the retry to land on partition 1, got %d", msg.Partition)
+		}
+	}
+
+	closeProducer(t, producer)
+	leader1.Close()
+	leader0.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerAuthorizationErrorNotRetried(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(leader.Addr(), leader.BrokerID()).
+		SetLeader("my_topic", 0, leader.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 0, ErrTopicAuthorizationFailed),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 3
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	select {
+	case msg := <-producer.Errors():
+		var authErr AuthorizationError
+		if !errors.As(msg.Err, &authErr) {
+			t.Fatalf("expected an AuthorizationError, got %v", msg.Err)
+		}
+		if authErr.Err != ErrTopicAuthorizationFailed || authErr.Resource != "my_topic" {
+			t.Errorf("unexpected authorization error %v", authErr)
+		}
+	case <-producer.Successes():
+		t.Fatal("expected the message to fail")
+	}
+
+	closeProducer(t, producer)
+
+	var produceRequests int
+	for _, rr := range leader.History() {
+		if _, ok := rr.Request.(*ProduceRequest); ok {
+			produceRequests++
+		}
+	}
+	if produceRequests != 1 {
+		t.Errorf("expected the authorization failure not to be retried, sent %d produce requests", produceRequests)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerOnAcknowledgement(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	for _, offset := range []int64{100, 105} {
+		prodSuccess := new(ProduceResponse)
+		prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+		prodSuccess.Blocks["my_topic"][0].Offset = offset
+		leader.Returns(prodSuccess)
+	}
+
+	var (
+		lock sync.Mutex
+		acks []ProducerAcknowledgement
+	)
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 5
+	config.Producer.Return.Successes = true
+	config.Producer.OnAcknowledgement = func(ack ProducerAcknowledgement) {
+		lock.Lock()
+		acks = append(acks, ack)
+		lock.Unlock()
+	}
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// send each batch on its own so that it fills exactly one produce request
+	for batch := 0; batch < 2; batch++ {
+		for i := 0; i < 5; i++ {
+			producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+		}
+		expectResults(t, producer, 5, 0)
+	}
+	closeProducer(t, producer)
+
+	lock.Lock()
+	defer lock.Unlock()
+	expected := []ProducerAcknowledgement{
+		{Topic: "my_topic", Partition: 0, Offset: 100, Count: 5},
+		{Topic: "my_topic", Partition: 0, Offset: 105, Count: 5},
+	}
+	require.Equal(t, expected, acks, "expected one acknowledgement per produce response")
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerLogAppendTime(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(leader.Addr(), leader.BrokerID()).
+		SetLeader("log_append", 0, leader.BrokerID()).
+		SetLeader("create_time", 0, leader.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+	})
+
+	appendTime := time.Unix(1700000000, 0)
+	createTime := time.Unix(1600000000, 0)
+
+	logAppendResponse := &ProduceResponse{Version: 2}
+	logAppendResponse.AddTopicPartition("log_append", 0, ErrNoError)
+	logAppendResponse.Blocks["log_append"][0].Timestamp = appendTime
+
+	createTimeResponse := &ProduceResponse{Version: 2}
+	createTimeResponse.AddTopicPartition("create_time", 0, ErrNoError)
+	createTimeResponse.Blocks["create_time"][0].Timestamp = time.Time{} // encoded as -1
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+		"ProduceRequest":  NewMockSequence(logAppendResponse, createTimeResponse),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_10_0_0
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, topic := range []string{"log_append", "create_time"} {
+		producer.Input() <- &ProducerMessage{Topic: topic, Value: StringEncoder(TestMessage), Timestamp: createTime}
+		select {
+		case msg := <-producer.Successes():
+			expected := createTime
+			if topic == "log_append" {
+				expected = appendTime
+			}
+			if !msg.Timestamp.Equal(expected) {
+				t.Errorf("%s: expected timestamp %v, got %v", topic, expected, msg.Timestamp)
+			}
+		case msg := <-producer.Errors():
+			t.Fatal(msg.Err)
+		}
+	}
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+// metadataPartitioner sends each message to the partition in its Metadata, or
+// to the first one, without requiring consistency.
+type metadataPartitioner struct{}
+
+func (metadataPartitioner) Partition(msg *ProducerMessage, numPartitions int32) (int32, error) {
+	if p, ok := msg.Metadata.(int32); ok && p < numPartitions {
+		return p, nil
+	}
+	return 0, nil
+}
+
+func (metadataPartitioner) RequiresConsistency() bool { return false }
+
+func TestAsyncProducerRepartitionUsesCachedMetadata(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, -1, nil, nil, nil, ErrLeaderNotAvailable)
+	metadataResponse.AddTopicPartition("my_topic", 1, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": NewMockWrapper(metadataResponse)})
+	leader.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": NewMockWrapper(metadataResponse)})
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+	if err := client.RefreshMetadata("my_topic"); err != nil {
+		t.Fatal(err)
+	}
+	metadataRequests := func() int {
+		return len(seedBroker.History()) + len(leader.History())
+	}
+	before := metadataRequests()
+
+	tp := &topicProducer{
+		parent:      &asyncProducer{client: &nopCloserClient{client}, conf: config},
+		topic:       "my_topic",
+		partitioner: NewRoundRobinPartitioner("my_topic"),
+	}
+	msg := &ProducerMessage{Topic: "my_topic", Partition: 0}
+	tp.repartitionMessage(msg)
+
+	if msg.Partition != 1 || !msg.moved {
+		t.Errorf("expected the message to move to partition 1, got partition %d", msg.Partition)
+	}
+	if after := metadataRequests(); after != before {
+		t.Errorf("expected no metadata refresh for the leaderless partition, got %d requests", after-before)
+	}
+}
+
+func TestAsyncProducerRepartitionedRetryDoesNotDelayHealthyPartition(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader0 := NewMockBroker(t, 2)
+	leader1 := NewMockBroker(t, 3)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader0.Addr(), leader0.BrokerID())
+	metadataResponse.AddBroker(leader1.Addr(), leader1.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader0.BrokerID(), nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, leader1.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+	})
+	leader0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 0, ErrNotLeaderForPartition),
+	})
+	leader1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadataResponse),
+		"ProduceRequest":  NewMockProduceResponse(t).SetError("my_topic", 1, ErrNoError),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 1
+	// a partition producer backing off for the moved retry would stall the test
+	config.Producer.Retry.Backoff = time.Minute
+	config.Producer.Partitioner = func(string) Partitioner { return metadataPartitioner{} }
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first message fails on partition 0 and is moved to partition 1,
+	// where the second one is sent
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Metadata: int32(0)}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Metadata: int32(1)}
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-producer.Errors():
+			t.Fatal(msg.Err)
+		case msg := <-producer.Successes():
+			if msg.Partition != 1 {
+				t.Errorf("expected the message to land on partition 1, got %d", msg.Partition)
+			}
+		case <-timeout:
+			t.Fatal("expected messages to the healthy partition not to wait for the retry backoff")
+		}
+	}
+
+	closeProducer(t, producer)
+	leader1.Close()
+	leader0.Close()
+	seedBroker.Close()
+}
@@ -2094,6 +2094,69 @@ func Test_stickyBalanceStrategy_Plan_AssignmentData(t *testing.T) {
 	}
 }
 
+// stickyMembers returns the subscriptions of members which kept their plan
+// assignment in their user data, like sarama members do.
+func stickyMembers(t *testing.T, s BalanceStrategy, plan BalanceStrategyPlan, generation int32, memberIDs ...string) map[string]ConsumerGroupMemberMetadata {
+	members := make(map[string]ConsumerGroupMemberMetadata, len(memberIDs))
+	for _, memberID := range memberIDs {
+		meta := ConsumerGroupMemberMetadata{Version: 1, Topics: []string{"topic1"}}
+		if plan != nil {
+			userData, err := s.AssignmentData(memberID, plan[memberID], generation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta.UserData = userData
+		}
+		members[memberID] = meta
+	}
+	return members
+}
+
+func Test_stickyBalanceStrategy_Plan_AddedMemberStealsMinimalPartitions(t *testing.T) {
+	s := NewBalanceStrategySticky()
+	topics := map[string][]int32{"topic1": {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}
+
+	plan1, err := s.Plan(stickyMembers(t, s, nil, 0, "consumer1", "consumer2", "consumer3"), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for memberID := range plan1 {
+		if n := len(plan1[memberID]["topic1"]); n != 4 {
+			t.Fatalf("expected 4 partitions for %s, got %v", memberID, plan1[memberID])
+		}
+	}
+
+	// a fourth member joins: each member gives up a single partition, which
+	// goes to the new member within the same generation
+	plan2, err := s.Plan(stickyMembers(t, s, plan1, 1, "consumer1", "consumer2", "consumer3", "consumer4"), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, memberID := range []string{"consumer1", "consumer2", "consumer3"} {
+		kept := plan2[memberID]["topic1"]
+		if len(kept) != 3 {
+			t.Fatalf("expected %s to keep 3 partitions, got %v", memberID, kept)
+		}
+		for _, partition := range kept {
+			if !int32SliceContains(plan1[memberID]["topic1"], partition) {
+				t.Errorf("expected %s to only keep its previous partitions, got %v", memberID, kept)
+			}
+		}
+	}
+	if n := len(plan2["consumer4"]["topic1"]); n != 3 {
+		t.Fatalf("expected consumer4 to take 3 partitions, got %v", plan2["consumer4"])
+	}
+}
+
+func int32SliceContains(s []int32, value int32) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func Test_stickyBalanceStrategy_Plan_data_race(t *testing.T) {
 	for i := 0; i < 1000; i++ {
 		go func(bs BalanceStrategy) {
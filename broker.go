@@ -30,6 +30,7 @@ type Broker struct {
 	connErr       error
 	lock          sync.Mutex
 	opened        int32
+	broken        int32 // set by the response receiver once it gives up on conn
 	responses     chan *responsePromise
 	done          chan bool
 
@@ -60,8 +61,21 @@ type Broker struct {
 	clientSessionReauthenticationTimeMs int64
 
 	throttleTimer *time.Timer
+	rateLimiter   atomic.Value // *requestRateLimiter set by the first Open, see Net.RateLimit
+
+	brokerAPIVersions apiVersionMap
+}
+
+// apiVersionRange is the range of versions of an API supported by a broker.
+type apiVersionRange struct {
+	minVersion int16
+	maxVersion int16
 }
 
+// apiVersionMap holds the version ranges advertised by a broker in its
+// ApiVersionsResponse, by API key.
+type apiVersionMap map[int16]*apiVersionRange
+
 // SASLMechanism specifies the SASL mechanism the client uses to authenticate with the broker
 type SASLMechanism string
 
@@ -158,7 +172,9 @@ func NewBroker(addr string) *Broker {
 // waiting for the connection to complete. This means that any subsequent operations on the broker will
 // block waiting for the connection to succeed or fail. To get the effect of a fully synchronous Open call,
 // follow it by a call to Connected(). The only errors Open will return directly are ConfigurationError or
-// AlreadyConnected. If conf is nil, the result of NewConfig() is used.
+// AlreadyConnected. If conf is nil, the result of NewConfig() is used. A broker whose connection is given up
+// on, as a read failed or the responses no longer match the requests sent, is no longer opened and is
+// connected again by the next Open.
 func (b *Broker) Open(conf *Config) error {
 	if !atomic.CompareAndSwapInt32(&b.opened, 0, 1) {
 		return ErrAlreadyConnected
@@ -175,6 +191,12 @@ func (b *Broker) Open(conf *Config) error {
 
 	usingApiVersionsRequests := conf.Version.IsAtLeast(V2_4_0_0) && conf.ApiVersionsRequest
 
+	if conf.Net.RateLimit.RequestsPerSecond > 0 {
+		// the limiter is kept across connections, so that reconnecting does
+		// not refill the bucket
+		b.rateLimiter.CompareAndSwap(nil, newRequestRateLimiter(conf.Net.RateLimit.RequestsPerSecond, conf.Net.RateLimit.Burst, conf.Net.RateLimit.Block))
+	}
+
 	b.lock.Lock()
 
 	if b.metricRegistry == nil {
@@ -182,104 +204,116 @@ func (b *Broker) Open(conf *Config) error {
 	}
 
 	go withRecover(func() {
-		defer func() {
-			b.lock.Unlock()
-
-			// Send an ApiVersionsRequest to identify the client (KIP-511).
-			// Ideally Sarama would use the response to control protocol versions,
-			// but for now just fire-and-forget just to send
-			if usingApiVersionsRequests {
-				_, err = b.ApiVersions(&ApiVersionsRequest{
-					Version:               3,
-					ClientSoftwareName:    defaultClientSoftwareName,
-					ClientSoftwareVersion: version(),
-				})
-				if err != nil {
-					Logger.Printf("Error while sending ApiVersionsRequest to broker %s: %s\n", b.addr, err)
-				}
-			}
-		}()
-		dialer := conf.getDialer()
-		b.conn, b.connErr = dialer.Dial("tcp", b.addr)
-		if b.connErr != nil {
-			Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
+		defer b.lock.Unlock()
+
+		if b.conn != nil {
+			// the connection was given up on by its response receiver
+			close(b.responses)
+			<-b.done
 			b.conn = nil
-			atomic.StoreInt32(&b.opened, 0)
-			return
-		}
-		if conf.Net.TLS.Enable {
-			b.conn = tls.Client(b.conn, validServerNameTLS(b.addr, conf.Net.TLS.Config))
 		}
 
-		b.conn = newBufConn(b.conn)
-		b.conf = conf
-
-		// Create or reuse the global metrics shared between brokers
-		b.incomingByteRate = metrics.GetOrRegisterMeter("incoming-byte-rate", b.metricRegistry)
-		b.requestRate = metrics.GetOrRegisterMeter("request-rate", b.metricRegistry)
-		b.fetchRate = metrics.GetOrRegisterMeter("consumer-fetch-rate", b.metricRegistry)
-		b.requestSize = getOrRegisterHistogram("request-size", b.metricRegistry)
-		b.requestLatency = getOrRegisterHistogram("request-latency-in-ms", b.metricRegistry)
-		b.outgoingByteRate = metrics.GetOrRegisterMeter("outgoing-byte-rate", b.metricRegistry)
-		b.responseRate = metrics.GetOrRegisterMeter("response-rate", b.metricRegistry)
-		b.responseSize = getOrRegisterHistogram("response-size", b.metricRegistry)
-		b.requestsInFlight = metrics.GetOrRegisterCounter("requests-in-flight", b.metricRegistry)
-		b.protocolRequestsRate = map[int16]metrics.Meter{}
-		// Do not gather metrics for seeded broker (only used during bootstrap) because they share
-		// the same id (-1) and are already exposed through the global metrics above
-		if b.id >= 0 && !metrics.UseNilMetrics {
-			b.registerMetrics()
-		}
+		b.connect(conf)
 
-		if conf.Net.SASL.Mechanism == SASLTypeOAuth && conf.Net.SASL.Version == SASLHandshakeV0 {
-			conf.Net.SASL.Version = SASLHandshakeV1
+		// Send an ApiVersionsRequest to identify the client (KIP-511) and
+		// learn the versions the broker supports, before any other request
+		// can be sent on the connection.
+		if usingApiVersionsRequests && b.conn != nil {
+			if err := b.sendApiVersionsRequest(); err != nil {
+				Logger.Printf("Error while sending ApiVersionsRequest to broker %s: %s\n", b.addr, err)
+			}
 		}
+	})
 
-		useSaslV0 := conf.Net.SASL.Version == SASLHandshakeV0 || conf.Net.SASL.Mechanism == SASLTypeGSSAPI
-		if conf.Net.SASL.Enable && useSaslV0 {
-			b.connErr = b.authenticateViaSASLv0()
+	return nil
+}
 
-			if b.connErr != nil {
-				err = b.conn.Close()
-				if err == nil {
-					DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
-				} else {
-					Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
-				}
-				b.conn = nil
-				atomic.StoreInt32(&b.opened, 0)
-				return
+// connect dials the broker and authenticates, then starts the response
+// receiver of the new connection. On failure connErr is set and the broker is
+// marked as not opened. b.lock must be held by caller.
+func (b *Broker) connect(conf *Config) {
+	dialer := conf.getDialer()
+	b.conn, b.connErr = dialer.Dial("tcp", b.addr)
+	if b.connErr != nil {
+		Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
+		b.conn = nil
+		atomic.StoreInt32(&b.opened, 0)
+		return
+	}
+	if conf.Net.TLS.Enable {
+		b.conn = tls.Client(b.conn, validServerNameTLS(b.addr, conf.Net.TLS.Config))
+	}
+
+	b.conn = newBufConn(b.conn)
+	b.conf = conf
+
+	// Create or reuse the global metrics shared between brokers
+	b.incomingByteRate = metrics.GetOrRegisterMeter("incoming-byte-rate", b.metricRegistry)
+	b.requestRate = metrics.GetOrRegisterMeter("request-rate", b.metricRegistry)
+	b.fetchRate = metrics.GetOrRegisterMeter("consumer-fetch-rate", b.metricRegistry)
+	b.requestSize = getOrRegisterHistogram("request-size", b.metricRegistry)
+	b.requestLatency = getOrRegisterHistogram("request-latency-in-ms", b.metricRegistry)
+	b.outgoingByteRate = metrics.GetOrRegisterMeter("outgoing-byte-rate", b.metricRegistry)
+	b.responseRate = metrics.GetOrRegisterMeter("response-rate", b.metricRegistry)
+	b.responseSize = getOrRegisterHistogram("response-size", b.metricRegistry)
+	b.requestsInFlight = metrics.GetOrRegisterCounter("requests-in-flight", b.metricRegistry)
+	b.protocolRequestsRate = map[int16]metrics.Meter{}
+	// Do not gather metrics for seeded broker (only used during bootstrap) because they share
+	// the same id (-1) and are already exposed through the global metrics above
+	if b.id >= 0 && !metrics.UseNilMetrics {
+		b.registerMetrics()
+	}
+
+	if conf.Net.SASL.Mechanism == SASLTypeOAuth && conf.Net.SASL.Version == SASLHandshakeV0 {
+		conf.Net.SASL.Version = SASLHandshakeV1
+	}
+
+	useSaslV0 := conf.Net.SASL.Version == SASLHandshakeV0 || conf.Net.SASL.Mechanism == SASLTypeGSSAPI
+	if conf.Net.SASL.Enable && useSaslV0 {
+		b.connErr = b.authenticateViaSASLv0()
+
+		if b.connErr != nil {
+			err := b.conn.Close()
+			if err == nil {
+				DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
+			} else {
+				Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
 			}
+			b.conn = nil
+			atomic.StoreInt32(&b.opened, 0)
+			return
 		}
+	}
 
-		b.done = make(chan bool)
-		b.responses = make(chan *responsePromise, b.conf.Net.MaxOpenRequests-1)
-
-		go withRecover(b.responseReceiver)
-		if conf.Net.SASL.Enable && !useSaslV0 {
-			b.connErr = b.authenticateViaSASLv1()
-			if b.connErr != nil {
-				close(b.responses)
-				<-b.done
-				err = b.conn.Close()
-				if err == nil {
-					DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
-				} else {
-					Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
-				}
-				b.conn = nil
+	b.done = make(chan bool)
+	b.responses = make(chan *responsePromise, b.conf.Net.MaxOpenRequests-1)
+	atomic.StoreInt32(&b.broken, 0)
+
+	go withRecover(b.responseReceiver)
+	if conf.Net.SASL.Enable && !useSaslV0 {
+		b.connErr = b.authenticateViaSASLv1()
+		if b.connErr != nil {
+			close(b.responses)
+			<-b.done
+			broken := atomic.LoadInt32(&b.broken) == 1
+			err := b.conn.Close()
+			if err == nil || broken {
+				DebugLogger.Printf("Closed connection to broker %s\n", b.addr)
+			} else {
+				Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+			}
+			b.conn = nil
+			if !broken {
 				atomic.StoreInt32(&b.opened, 0)
-				return
 			}
+			return
 		}
-		if b.id >= 0 {
-			DebugLogger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
-		} else {
-			DebugLogger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
-		}
-	})
-
-	return nil
+	}
+	if b.id >= 0 {
+		DebugLogger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
+	} else {
+		DebugLogger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
+	}
 }
 
 func (b *Broker) ResponseSize() int {
@@ -295,7 +329,7 @@ func (b *Broker) Connected() (bool, error) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	return b.conn != nil, b.connErr
+	return b.conn != nil && atomic.LoadInt32(&b.broken) == 0, b.connErr
 }
 
 // TLSConnectionState returns the client's TLS connection state. The second return value is false if this is not a tls connection or the connection has not yet been established.
@@ -318,6 +352,11 @@ func (b *Broker) TLSConnectionState() (state tls.ConnectionState, ok bool) {
 
 // Close closes the broker resources
 func (b *Broker) Close() error {
+	if l, _ := b.rateLimiter.Load().(*requestRateLimiter); l != nil {
+		// requests waiting for the rate limit have not taken b.lock yet
+		l.interrupt()
+	}
+
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
@@ -328,12 +367,19 @@ func (b *Broker) Close() error {
 	close(b.responses)
 	<-b.done
 
+	// a broken connection was already closed by the response receiver, which
+	// also marked the broker as not opened, so that it may be opened again
+	broken := atomic.LoadInt32(&b.broken) == 1
 	err := b.conn.Close()
+	if broken {
+		err = nil
+	}
 
 	b.conn = nil
 	b.connErr = nil
 	b.done = nil
 	b.responses = nil
+	b.brokerAPIVersions = nil
 
 	b.metricRegistry.UnregisterAll()
 
@@ -343,7 +389,9 @@ func (b *Broker) Close() error {
 		Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
 	}
 
-	atomic.StoreInt32(&b.opened, 0)
+	if !broken {
+		atomic.StoreInt32(&b.opened, 0)
+	}
 
 	return err
 }
@@ -354,6 +402,11 @@ func (b *Broker) ID() int32 {
 }
 
 // Addr returns the broker address as either retrieved from Kafka's metadata or passed to NewBroker.
+//
+// Kafka only advertises a single endpoint per broker to clients: the one of the listener the
+// metadata request was received on. On clusters with several listeners (e.g. PLAINTEXT and SSL)
+// the endpoint is therefore selected by bootstrapping against the matching listener's port,
+// there is no list of endpoints to choose from in the metadata response.
 func (b *Broker) Addr() string {
 	return b.addr
 }
@@ -435,9 +488,16 @@ type ProduceCallback func(*ProduceResponse, error)
 //
 // Make sure not to Close the broker in the callback as it will lead to a deadlock.
 func (b *Broker) AsyncProduce(request *ProduceRequest, cb ProduceCallback) error {
+	if err := b.waitForRateLimit(); err != nil {
+		return err
+	}
+
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	if err := b.restrictApiVersion(request); err != nil {
+		return err
+	}
 	needAcks := request.RequiredAcks != NoResponse
 	// Use a nil promise when no acks is required
 	var promise *responsePromise
@@ -459,7 +519,7 @@ func (b *Broker) AsyncProduce(request *ProduceRequest, cb ProduceCallback) error
 
 				if err := versionedDecode(packets, res, request.version(), metricRegistry); err != nil {
 					// Malformed response
-					cb(nil, err)
+					cb(nil, ResponseDecodingError{Err: err})
 					return
 				}
 
@@ -625,6 +685,107 @@ func (b *Broker) ApiVersions(request *ApiVersionsRequest) (*ApiVersionsResponse,
 	return response, nil
 }
 
+// sendApiVersionsRequest identifies the client to the broker using the
+// flexible v3 ApiVersionsRequest. Brokers that do not support v3 reply with a
+// v0 response carrying ErrUnsupportedVersion and their own supported range,
+// in which case the request is retried at the highest version they accept.
+// The version ranges of the response are kept to restrict the versions of
+// later requests to the ones the broker supports.
+// b.lock must be held by caller
+func (b *Broker) sendApiVersionsRequest() error {
+	request := &ApiVersionsRequest{
+		Version:               3,
+		ClientSoftwareName:    defaultClientSoftwareName,
+		ClientSoftwareVersion: version(),
+	}
+	response := new(ApiVersionsResponse)
+	if err := b.sendAndReceiveLocked(request, response); err != nil {
+		return err
+	}
+	if KError(response.ErrorCode) != ErrUnsupportedVersion {
+		return b.setBrokerAPIVersions(response)
+	}
+
+	fallback := int16(-1)
+	for _, key := range response.ApiKeys {
+		if key.ApiKey == request.key() {
+			fallback = key.MaxVersion
+			break
+		}
+	}
+	if fallback < 0 || fallback >= request.Version {
+		return ErrUnsupportedVersion
+	}
+	DebugLogger.Printf("broker/%d ApiVersionsRequest v%d not supported by %s, retrying with v%d\n",
+		b.id, request.Version, b.addr, fallback)
+
+	response = new(ApiVersionsResponse)
+	if err := b.sendAndReceiveLocked(&ApiVersionsRequest{Version: fallback}, response); err != nil {
+		return err
+	}
+	return b.setBrokerAPIVersions(response)
+}
+
+// b.lock must be held by caller
+func (b *Broker) setBrokerAPIVersions(response *ApiVersionsResponse) error {
+	if response.ErrorCode != int16(ErrNoError) {
+		return KError(response.ErrorCode)
+	}
+	versions := make(apiVersionMap, len(response.ApiKeys))
+	for _, key := range response.ApiKeys {
+		versions[key.ApiKey] = &apiVersionRange{minVersion: key.MinVersion, maxVersion: key.MaxVersion}
+	}
+	b.brokerAPIVersions = versions
+	return nil
+}
+
+// advertisedApiVersions returns the versions of the API the broker advertised
+// in its ApiVersionsResponse, or nil if it did not list the API or was not
+// asked for its versions.
+func (b *Broker) advertisedApiVersions(key int16) *apiVersionRange {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.brokerAPIVersions[key]
+}
+
+// restrictApiVersion lowers the version of the request to the newest one the
+// broker advertised for its API. Only bodies which report the version their
+// fields need (see fieldsVersioner) are lowered, and never below that version.
+// A request is never raised, as its body may not hold what a newer version
+// needs. ErrUnsupportedVersion is returned when the request is older than the
+// broker supports, or when it cannot be lowered far enough. The version is
+// left as is for brokers which did not answer an ApiVersionsRequest, or did
+// not list the API.
+// b.lock must be held by caller
+func (b *Broker) restrictApiVersion(pb protocolBody) error {
+	versions := b.brokerAPIVersions[pb.key()]
+	if versions == nil {
+		return nil
+	}
+	version := pb.version()
+	if version < versions.minVersion {
+		return fmt.Errorf("%w: %T v%d is older than v%d, the oldest version %s supports",
+			ErrUnsupportedVersion, pb, version, versions.minVersion, b.addr)
+	}
+	if version <= versions.maxVersion {
+		return nil
+	}
+	fv, ok := pb.(fieldsVersioner)
+	if !ok {
+		return fmt.Errorf("%w: %T v%d is newer than v%d, the newest version %s supports",
+			ErrUnsupportedVersion, pb, version, versions.maxVersion, b.addr)
+	}
+	if fv.fieldsVersion() > versions.maxVersion {
+		return fmt.Errorf("%w: %T needs v%d for the fields set, %s supports up to v%d",
+			ErrUnsupportedVersion, pb, fv.fieldsVersion(), b.addr, versions.maxVersion)
+	}
+	DebugLogger.Printf("broker/%d %T v%d not supported by %s, using v%d\n",
+		b.id, pb, version, b.addr, versions.maxVersion)
+	pb.setVersion(versions.maxVersion)
+	return nil
+}
+
 // CreateTopics send a create topic request and returns create topic response
 func (b *Broker) CreateTopics(request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
 	response := new(CreateTopicsResponse)
@@ -675,6 +836,32 @@ func (b *Broker) AlterPartitionReassignments(request *AlterPartitionReassignment
 	return response, nil
 }
 
+// OffsetForLeaderEpoch sends an offset for leader epoch request and returns
+// the offset for leader epoch response
+func (b *Broker) OffsetForLeaderEpoch(request *OffsetForLeaderEpochRequest) (*OffsetForLeaderEpochResponse, error) {
+	response := new(OffsetForLeaderEpochResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ElectLeaders sends an elect leaders request and returns the elect leaders
+// response
+func (b *Broker) ElectLeaders(request *ElectLeadersRequest) (*ElectLeadersResponse, error) {
+	response := new(ElectLeadersResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 // ListPartitionReassignments sends a list partition reassignments request and
 // returns list partition reassignments response
 func (b *Broker) ListPartitionReassignments(request *ListPartitionReassignmentsRequest) (*ListPartitionReassignmentsResponse, error) {
@@ -906,6 +1093,18 @@ func (b *Broker) AlterUserScramCredentials(req *AlterUserScramCredentialsRequest
 	return res, nil
 }
 
+// DescribeCluster sends a describe cluster request and returns describe cluster response or error
+func (b *Broker) DescribeCluster(request *DescribeClusterRequest) (*DescribeClusterResponse, error) {
+	response := new(DescribeClusterResponse)
+
+	err := b.sendAndReceive(request, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
 // DescribeClientQuotas sends a request to get the broker's quotas
 func (b *Broker) DescribeClientQuotas(request *DescribeClientQuotasRequest) (*DescribeClientQuotasResponse, error) {
 	response := new(DescribeClientQuotasResponse)
@@ -930,6 +1129,39 @@ func (b *Broker) AlterClientQuotas(request *AlterClientQuotasRequest) (*AlterCli
 	return response, nil
 }
 
+// SendRaw sends a hand-crafted request to the broker and returns the raw
+// response body with the response header stripped. The request header
+// (api key, version, correlation id and client id) is framed around the
+// supplied body, which must already be encoded for the given api key and
+// version. It is meant for protocol debugging and for experimenting with APIs
+// sarama does not implement, neither the body nor the response is validated.
+// As the body cannot be re-encoded, a version outside of the range the broker
+// advertised in its ApiVersionsResponse fails with ErrUnsupportedVersion.
+func (b *Broker) SendRaw(apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	if err := b.waitForRateLimit(); err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	req := newRawRequest(apiKey, apiVersion, body)
+	if err := b.restrictApiVersion(req); err != nil {
+		return nil, err
+	}
+	promise, err := b.send(req, true, req.responseHeaderVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case buf := <-promise.packets:
+		return buf, nil
+	case err := <-promise.errors:
+		return nil, err
+	}
+}
+
 // readFull ensures the conn ReadDeadline has been setup before making a
 // call to io.ReadFull
 func (b *Broker) readFull(buf []byte) (n int, err error) {
@@ -950,6 +1182,33 @@ func (b *Broker) write(buf []byte) (n int, err error) {
 	return b.conn.Write(buf)
 }
 
+// writeBuffers is like write for a packet split over several buffers, which
+// are handed to the kernel in a single writev call on plain TCP connections.
+func (b *Broker) writeBuffers(bufs net.Buffers) (int, error) {
+	if err := b.conn.SetWriteDeadline(time.Now().Add(b.conf.Net.WriteTimeout)); err != nil {
+		return 0, err
+	}
+
+	// net.Buffers only uses writev when writing to the connection itself,
+	// the bufConn wrapper merely buffers reads
+	var conn net.Conn = b.conn
+	if bc, ok := conn.(*bufConn); ok {
+		conn = bc.Conn
+	}
+	n, err := bufs.WriteTo(conn)
+	return int(n), err
+}
+
+// gathersWrites reports whether the request is encoded without copying the
+// values of its messages, see Producer.ZeroCopy.
+func (b *Broker) gathersWrites(rb protocolBody) bool {
+	if !b.conf.Producer.ZeroCopy || b.conf.Producer.Compression != CompressionNone {
+		return false
+	}
+	_, ok := rb.(*ProduceRequest)
+	return ok
+}
+
 // b.lock must be held by caller
 func (b *Broker) send(rb protocolBody, promiseResponse bool, responseHeaderVersion int16) (*responsePromise, error) {
 	var promise *responsePromise
@@ -984,6 +1243,10 @@ func (b *Broker) sendWithPromise(rb protocolBody, promise *responsePromise) erro
 		return ErrNotConnected
 	}
 
+	if atomic.LoadInt32(&b.broken) == 1 {
+		return ErrNotConnected
+	}
+
 	if b.clientSessionReauthenticationTimeMs > 0 && currentUnixMilli() > b.clientSessionReauthenticationTimeMs {
 		err := b.authenticateViaSASLv1()
 		if err != nil {
@@ -1001,9 +1264,18 @@ func (b *Broker) sendInternal(rb protocolBody, promise *responsePromise) error {
 	}
 
 	req := &request{correlationID: b.correlationID, clientID: b.conf.ClientID, body: rb}
-	buf, err := encode(req, b.metricRegistry)
-	if err != nil {
-		return err
+	var bufs net.Buffers
+	if b.gathersWrites(rb) {
+		var err error
+		if bufs, err = encodeGather(req, b.metricRegistry); err != nil {
+			return err
+		}
+	} else {
+		buf, err := encode(req, b.metricRegistry)
+		if err != nil {
+			return err
+		}
+		bufs = net.Buffers{buf}
 	}
 
 	// check and wait if throttled
@@ -1012,14 +1284,22 @@ func (b *Broker) sendInternal(rb protocolBody, promise *responsePromise) error {
 	requestTime := time.Now()
 	// Will be decremented in responseReceiver (except error or request with NoResponse)
 	b.addRequestInFlightMetrics(1)
-	bytes, err := b.write(buf)
+	// the correlation ID is taken before writing, as the response receiver
+	// may read the response before the write returns
+	atomic.AddInt32(&b.correlationID, 1)
+	var bytes int
+	var err error
+	if len(bufs) == 1 {
+		bytes, err = b.write(bufs[0])
+	} else {
+		bytes, err = b.writeBuffers(bufs)
+	}
 	b.updateOutgoingCommunicationMetrics(bytes)
 	b.updateProtocolMetrics(rb)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
 		return err
 	}
-	b.correlationID++
 
 	if promise == nil {
 		// Record request latency without the response
@@ -1034,11 +1314,30 @@ func (b *Broker) sendInternal(rb protocolBody, promise *responsePromise) error {
 	return nil
 }
 
+// sendAndReceive sends a request and decodes its response into res. When res
+// is nil no response is expected and nil is returned once the request is sent.
+// A ResponseDecodingError means a response was received but could not be
+// decoded, res may then be partially filled in. Any other error means the
+// request could not be sent or no response was received.
 func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
+	if err := b.waitForRateLimit(); err != nil {
+		return err
+	}
+
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	return b.sendAndReceiveLocked(req, res)
+}
+
+// b.lock must be held by caller
+func (b *Broker) sendAndReceiveLocked(req protocolBody, res protocolBody) error {
+	if err := b.restrictApiVersion(req); err != nil {
+		return err
+	}
 	responseHeaderVersion := int16(-1)
 	if res != nil {
+		// the header version of some responses depends on their version
+		res.setVersion(req.version())
 		responseHeaderVersion = res.headerVersion()
 	}
 
@@ -1051,7 +1350,7 @@ func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
 		return nil
 	}
 
-	err = handleResponsePromise(req, res, promise, b.metricRegistry)
+	err = handleResponsePromise(req, res, promise, b.metricRegistry, b.conf.Consumer.LenientDecompression)
 	if err != nil {
 		return err
 	}
@@ -1061,10 +1360,13 @@ func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
 	return nil
 }
 
-func handleResponsePromise(req protocolBody, res protocolBody, promise *responsePromise, metricRegistry metrics.Registry) error {
+func handleResponsePromise(req protocolBody, res protocolBody, promise *responsePromise, metricRegistry metrics.Registry, lenient bool) error {
 	select {
 	case buf := <-promise.packets:
-		return versionedDecode(buf, res, req.version(), metricRegistry)
+		if err := versionedDecodeLenient(buf, res, req.version(), metricRegistry, lenient); err != nil {
+			return ResponseDecodingError{Err: err}
+		}
+		return nil
 	case err := <-promise.errors:
 		return err
 	}
@@ -1159,57 +1461,141 @@ func (b *Broker) encode(pe packetEncoder, version int16) (err error) {
 
 func (b *Broker) responseReceiver() {
 	var dead error
+	// the promises of the requests sent on the connection which were not
+	// answered yet, in the order the requests were sent
+	var pending []*responsePromise
+
+	for {
+		if len(pending) == 0 {
+			promise, ok := <-b.responses
+			if !ok {
+				break
+			}
+			pending = append(pending, promise)
+		}
 
-	for response := range b.responses {
 		if dead != nil {
-			// This was previously incremented in send() and
-			// we are not calling updateIncomingCommunicationMetrics()
-			b.addRequestInFlightMetrics(-1)
-			response.handle(nil, dead)
+			for _, promise := range pending {
+				// This was previously incremented in send() and
+				// we are not calling updateIncomingCommunicationMetrics()
+				b.addRequestInFlightMetrics(-1)
+				promise.handle(nil, dead)
+			}
+			pending = pending[:0]
 			continue
 		}
 
-		headerLength := getHeaderLength(response.headerVersion)
-		header := make([]byte, headerLength)
+		pending, dead = b.receiveResponse(pending)
+	}
+	close(b.done)
+}
+
+// receiveResponse reads the next response and hands it to the pending promise
+// with the same correlation ID, which is removed from pending. Responses are
+// matched by correlation ID rather than by position: a response to a request
+// whose promise was not read from b.responses yet waits for it, and responses
+// to requests which were already answered are logged and discarded. A
+// response to a request which was never sent desyncs the connection. The
+// returned error is set once nothing more can be read from the connection.
+func (b *Broker) receiveResponse(pending []*responsePromise) ([]*responsePromise, error) {
+	// the length and correlation ID, the tagged fields of flexible response
+	// headers depend on the request being answered
+	var header [8]byte
+	bytesRead, err := b.readFull(header[:])
+	if err != nil {
+		return b.failResponse(pending, 0, bytesRead, err)
+	}
+	decodedHeader := responseHeader{}
+	if err := versionedDecode(header[:], &decodedHeader, 0, b.metricRegistry); err != nil {
+		return b.failResponse(pending, 0, bytesRead, desyncError(err.Error()))
+	}
 
-		bytesReadHeader, err := b.readFull(header)
-		requestLatency := time.Since(response.requestTime)
+	id := decodedHeader.correlationID
+	i, pending, open := b.findPromise(pending, id)
+	if i < 0 {
+		if !open || id >= atomic.LoadInt32(&b.correlationID) {
+			return b.failResponse(pending, 0, bytesRead, desyncError(fmt.Sprintf("received a response with correlation ID %d which was not sent", id)))
+		}
+		Logger.Printf("broker/%d discarding response with correlation ID %d which was already answered\n", b.ID(), id)
+		n, err := b.readFull(make([]byte, decodedHeader.length-4))
 		if err != nil {
-			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
-			dead = err
-			response.handle(nil, err)
-			continue
+			return b.failResponse(pending, 0, bytesRead+n, err)
 		}
+		return pending, nil
+	}
 
-		decodedHeader := responseHeader{}
-		err = versionedDecode(header, &decodedHeader, response.headerVersion, b.metricRegistry)
+	promise := pending[i]
+	if getHeaderLength(promise.headerVersion) > 8 {
+		var tags [1]byte
+		n, err := b.readFull(tags[:])
+		bytesRead += n
 		if err != nil {
-			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
-			dead = err
-			response.handle(nil, err)
-			continue
+			return b.failResponse(pending, i, bytesRead, err)
 		}
-		if decodedHeader.correlationID != response.correlationID {
-			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
-			// TODO if decoded ID < cur ID, discard until we catch up
-			// TODO if decoded ID > cur ID, save it so when cur ID catches up we have a response
-			dead = PacketDecodingError{fmt.Sprintf("correlation ID didn't match, wanted %d, got %d", response.correlationID, decodedHeader.correlationID)}
-			response.handle(nil, dead)
-			continue
+		// we don't support actual tags yet
+		if tags[0] != 0 {
+			return b.failResponse(pending, i, bytesRead, desyncError("response header with tagged fields"))
 		}
+	}
 
-		buf := make([]byte, decodedHeader.length-int32(headerLength)+4)
-		bytesReadBody, err := b.readFull(buf)
-		b.updateIncomingCommunicationMetrics(bytesReadHeader+bytesReadBody, requestLatency)
-		if err != nil {
-			dead = err
-			response.handle(nil, err)
-			continue
-		}
+	buf := make([]byte, decodedHeader.length-int32(getHeaderLength(promise.headerVersion))+4)
+	n, err := b.readFull(buf)
+	bytesRead += n
+	if err != nil {
+		return b.failResponse(pending, i, bytesRead, err)
+	}
+
+	pending = append(pending[:i], pending[i+1:]...)
+	b.updateIncomingCommunicationMetrics(bytesRead, time.Since(promise.requestTime))
+	promise.handle(buf, nil)
+	return pending, nil
+}
 
-		response.handle(buf, nil)
+// findPromise returns the index of the pending promise with the given
+// correlation ID, reading further promises from b.responses while the ID is
+// one of a request sent after them. It returns -1 if there is no such promise,
+// in which case open reports whether b.responses is still open.
+func (b *Broker) findPromise(pending []*responsePromise, id int32) (i int, _ []*responsePromise, open bool) {
+	for {
+		for i, promise := range pending {
+			if promise.correlationID == id {
+				return i, pending, true
+			}
+		}
+		if id < pending[len(pending)-1].correlationID || id >= atomic.LoadInt32(&b.correlationID) {
+			return -1, pending, true
+		}
+		promise, ok := <-b.responses
+		if !ok {
+			return -1, pending, false
+		}
+		pending = append(pending, promise)
 	}
-	close(b.done)
+}
+
+// failResponse gives up on the connection and fails the pending promise at
+// index i with err, which is returned so that the other promises fail with it
+// as well. A read which failed or timed out may have left the rest of a
+// response on the connection, and a desync means nothing read next can be
+// trusted, so the connection is closed and the broker is marked as not opened
+// before any request fails: a caller seeing the error may Open it again.
+func (b *Broker) failResponse(pending []*responsePromise, i int, bytesRead int, err error) ([]*responsePromise, error) {
+	Logger.Printf("broker/%d giving up on the connection to %s: %s\n", b.ID(), b.addr, err)
+	atomic.StoreInt32(&b.broken, 1)
+	_ = b.conn.Close()
+	atomic.StoreInt32(&b.opened, 0)
+
+	promise := pending[i]
+	pending = append(pending[:i], pending[i+1:]...)
+	b.updateIncomingCommunicationMetrics(bytesRead, time.Since(promise.requestTime))
+	promise.handle(nil, err)
+	return pending, err
+}
+
+// desyncError is the error for responses which no longer line up with the
+// requests sent on the connection.
+func desyncError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrProtocolDesync, reason)
 }
 
 func getHeaderLength(headerVersion int16) int8 {
@@ -1244,7 +1630,7 @@ func (b *Broker) authenticateViaSASLv1() error {
 			Logger.Printf("Error while performing SASL handshake %s\n", b.addr)
 			return handshakeErr
 		}
-		handshakeErr = handleResponsePromise(handshakeRequest, handshakeResponse, prom, metricRegistry)
+		handshakeErr = handleResponsePromise(handshakeRequest, handshakeResponse, prom, metricRegistry, false)
 		if handshakeErr != nil {
 			Logger.Printf("Error while performing SASL handshake %s\n", b.addr)
 			return handshakeErr
@@ -1264,7 +1650,7 @@ func (b *Broker) authenticateViaSASLv1() error {
 			Logger.Printf("Error while performing SASL Auth %s\n", b.addr)
 			return nil, authErr
 		}
-		authErr = handleResponsePromise(authenticateRequest, authenticateResponse, prom, metricRegistry)
+		authErr = handleResponsePromise(authenticateRequest, authenticateResponse, prom, metricRegistry, false)
 		if authErr != nil {
 			Logger.Printf("Error while performing SASL Auth %s\n", b.addr)
 			return nil, authErr
@@ -1320,7 +1706,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 		Logger.Printf("Failed to send SASL handshake %s: %s\n", b.addr, err.Error())
 		return err
 	}
-	b.correlationID++
+	atomic.AddInt32(&b.correlationID, 1)
 
 	header := make([]byte, 8) // response header
 	_, err = b.readFull(header)
@@ -1491,7 +1877,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv0() error {
 			Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
 			return err
 		}
-		b.correlationID++
+		atomic.AddInt32(&b.correlationID, 1)
 		header := make([]byte, 4)
 		_, err = b.readFull(header)
 		if err != nil {
@@ -1714,6 +2100,27 @@ func (b *Broker) waitIfThrottled() {
 	}
 }
 
+// waitForRateLimit applies the client-side Net.RateLimit, either blocking
+// until the request is permitted or failing with ErrRequestRateLimited. A
+// wait ends with ErrNotConnected when the broker is closed. b.lock must not be
+// held by caller, so that the other users of the broker are not held up.
+func (b *Broker) waitForRateLimit() error {
+	l, _ := b.rateLimiter.Load().(*requestRateLimiter)
+	if l == nil {
+		return nil
+	}
+	if !l.block {
+		if !l.allow() {
+			return ErrRequestRateLimited
+		}
+		return nil
+	}
+	if !l.wait() {
+		return ErrNotConnected
+	}
+	return nil
+}
+
 func (b *Broker) updateThrottleMetric(throttleTime time.Duration) {
 	if b.brokerThrottleTime != nil {
 		throttleTimeInMs := int64(throttleTime / time.Millisecond)
@@ -2,10 +2,14 @@ package sarama
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1438,6 +1442,65 @@ func BenchmarkBroker_No_Metrics_Open(b *testing.B) {
 	}
 }
 
+// BenchmarkBrokerWriteBurst compares writing a burst of small requests to
+// the connection one Write per request, as the broker does, against joining
+// them in a single Write. Requests are written synchronously under the broker
+// lock, so there is never a queue of requests to join; this quantifies what
+// pipelining the writes could save.
+func BenchmarkBrokerWriteBurst(b *testing.B) {
+	const burst = 16
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+
+	requests := make([][]byte, burst)
+	var joined []byte
+	for i := range requests {
+		req := &request{correlationID: int32(i), clientID: "bench", body: &MetadataRequest{Topics: []string{"my_topic"}}}
+		if requests[i], err = encode(req, nil); err != nil {
+			b.Fatal(err)
+		}
+		joined = append(joined, requests[i]...)
+	}
+
+	b.Run("per-request", func(b *testing.B) {
+		b.SetBytes(int64(len(joined)))
+		for i := 0; i < b.N; i++ {
+			for _, buf := range requests {
+				if _, err := conn.Write(buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		b.SetBytes(int64(len(joined)))
+		for i := 0; i < b.N; i++ {
+			if _, err := conn.Write(joined); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func Test_handleThrottledResponse(t *testing.T) {
 	mb := NewMockBroker(nil, 0)
 	defer mb.Close()
@@ -1533,3 +1596,814 @@ func Test_handleThrottledResponse(t *testing.T) {
 		}
 	})
 }
+
+func TestBrokerApiVersionsFallback(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockSequence(
+			&ApiVersionsResponse{
+				Version:   0,
+				ErrorCode: int16(ErrUnsupportedVersion),
+				ApiKeys:   []ApiVersionsResponseKey{{ApiKey: 18, MinVersion: 0, MaxVersion: 2}},
+			},
+			NewMockApiVersionsResponse(t),
+		),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V2_4_0_0
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	var versions []int16
+	deadline := time.Now().Add(5 * time.Second)
+	for len(versions) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		versions = versions[:0]
+		for _, rr := range mb.History() {
+			if req, ok := rr.Request.(*ApiVersionsRequest); ok {
+				versions = append(versions, req.Version)
+			}
+		}
+	}
+
+	if len(versions) != 2 || versions[0] != 3 || versions[1] != 2 {
+		t.Fatalf("expected ApiVersionsRequest v3 followed by v2, got %v", versions)
+	}
+}
+
+func TestBrokerSendRaw(t *testing.T) {
+	for _, version := range []int16{1, 9} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			mb := NewMockBroker(t, 0)
+			defer mb.Close()
+
+			mb.SetHandlerByMap(map[string]MockResponse{
+				"MetadataRequest": NewMockMetadataResponse(t).
+					SetBroker(mb.Addr(), mb.BrokerID()).
+					SetLeader("my_topic", 0, mb.BrokerID()),
+			})
+
+			conf := NewTestConfig()
+			conf.ApiVersionsRequest = false
+
+			broker := NewBroker(mb.Addr())
+			if err := broker.Open(conf); err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = broker.Close() }()
+
+			body, err := encode(&MetadataRequest{Version: version, Topics: []string{"my_topic"}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := broker.SendRaw(3, version, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response := new(MetadataResponse)
+			if err := versionedDecode(raw, response, version, nil); err != nil {
+				t.Fatalf("failed to decode raw response: %v", err)
+			}
+			if len(response.Brokers) != 1 || response.Brokers[0].ID() != mb.BrokerID() {
+				t.Errorf("unexpected brokers in raw response: %v", response.Brokers)
+			}
+			if len(response.Topics) != 1 || response.Topics[0].Name != "my_topic" {
+				t.Errorf("unexpected topics in raw response: %v", response.Topics)
+			}
+
+			history := mb.History()
+			if len(history) != 1 {
+				t.Fatalf("expected one request to be sent, got %d", len(history))
+			}
+			if req, ok := history[0].Request.(*MetadataRequest); !ok || req.Version != version {
+				t.Errorf("expected MetadataRequest v%d on the wire, got %#v", version, history[0].Request)
+			}
+		})
+	}
+}
+
+func TestBrokerProduceZeroCopy(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	conf.Version = V0_11_0_0
+	conf.Producer.ZeroCopy = true
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	value := bytes.Repeat([]byte{'v'}, 4*gatherThreshold)
+	if _, err := broker.Produce(gatherTestProduceRequest(value)); err != nil {
+		t.Fatal(err)
+	}
+
+	history := mb.History()
+	if len(history) != 1 {
+		t.Fatalf("expected one request to be sent, got %d", len(history))
+	}
+	req, ok := history[0].Request.(*ProduceRequest)
+	if !ok {
+		t.Fatalf("expected a ProduceRequest on the wire, got %#v", history[0].Request)
+	}
+	records := req.records["my_topic"][0].RecordBatch.Records
+	if len(records) != 3 || !bytes.Equal(records[1].Value, value) || !bytes.Equal(records[2].Value, value) {
+		t.Errorf("unexpected records received by the broker: %v", records)
+	}
+}
+
+func TestBrokerDiscardsDuplicateResponses(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	body, err := encode(&MetadataResponse{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a misbehaving broker answering the first request twice
+	serverDone := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverDone <- err
+			return
+		}
+		defer conn.Close()
+
+		for i := 0; i < 2; i++ {
+			var length [4]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				serverDone <- err
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(length[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				serverDone <- err
+				return
+			}
+
+			res := make([]byte, 8, 8+len(body))
+			binary.BigEndian.PutUint32(res[0:], uint32(4+len(body)))
+			copy(res[4:], req[4:8]) // correlation ID
+			res = append(res, body...)
+
+			writes := 1
+			if i == 0 {
+				writes = 2
+			}
+			for j := 0; j < writes; j++ {
+				if _, err := conn.Write(res); err != nil {
+					serverDone <- err
+					return
+				}
+			}
+		}
+		serverDone <- nil
+	}()
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	for i := 0; i < 2; i++ {
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+	if err := <-serverDone; err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected || err != nil {
+		t.Errorf("expected the broker to stay connected, got %v, %v", connected, err)
+	}
+}
+
+func TestBrokerReconnectsAfterDesync(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	body, err := encode(&MetadataResponse{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first connection answers with a correlation ID which was never
+	// sent, the second one behaves
+	serverDone := make(chan error, 1)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				serverDone <- err
+				return
+			}
+			var length [4]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				serverDone <- err
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(length[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				serverDone <- err
+				return
+			}
+
+			res := make([]byte, 8, 8+len(body))
+			binary.BigEndian.PutUint32(res[0:], uint32(4+len(body)))
+			copy(res[4:], req[4:8]) // correlation ID
+			if i == 0 {
+				res[7] += 7
+			}
+			res = append(res, body...)
+			if _, err := conn.Write(res); err != nil {
+				serverDone <- err
+				return
+			}
+
+			if i == 0 {
+				// the client gives up on the connection
+				if _, err := conn.Read(length[:]); err != io.EOF {
+					serverDone <- fmt.Errorf("expected the desynced connection to be closed, got %v", err)
+					return
+				}
+			}
+			conn.Close()
+		}
+		serverDone <- nil
+	}()
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrProtocolDesync) {
+		t.Fatalf("expected ErrProtocolDesync, got %v", err)
+	}
+	if connected, _ := broker.Connected(); connected {
+		t.Fatal("expected the desynced broker not to be connected")
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected until the broker is opened again, got %v", err)
+	}
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatalf("expected the request to succeed on a new connection, got %v", err)
+	}
+	if err := <-serverDone; err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected || err != nil {
+		t.Errorf("expected the broker to be connected, got %v, %v", connected, err)
+	}
+}
+
+func TestBrokerReconnectsAfterReadTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	body, err := encode(&MetadataResponse{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first connection never answers, the second one behaves
+	serverDone := make(chan error, 1)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				serverDone <- err
+				return
+			}
+			var length [4]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				serverDone <- err
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(length[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				serverDone <- err
+				return
+			}
+
+			if i == 0 {
+				// the client gives up on the connection
+				if _, err := conn.Read(length[:]); err != io.EOF {
+					serverDone <- fmt.Errorf("expected the timed out connection to be closed, got %v", err)
+					return
+				}
+				conn.Close()
+				continue
+			}
+
+			res := make([]byte, 8, 8+len(body))
+			binary.BigEndian.PutUint32(res[0:], uint32(4+len(body)))
+			copy(res[4:], req[4:8]) // correlation ID
+			res = append(res, body...)
+			if _, err := conn.Write(res); err != nil {
+				serverDone <- err
+				return
+			}
+			conn.Close()
+		}
+		serverDone <- nil
+	}()
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	conf.Net.ReadTimeout = 100 * time.Millisecond
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	var netErr net.Error
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatalf("expected the request to succeed on a new connection, got %v", err)
+	}
+	if err := <-serverDone; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrokerConcurrentSendsDuringDesync(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	apiVersions, err := encode(&ApiVersionsResponse{
+		Version: 3,
+		ApiKeys: []ApiVersionsResponseKey{
+			{Version: 3, ApiKey: 3, MinVersion: 0, MaxVersion: 0},
+			{Version: 3, ApiKey: 18, MinVersion: 0, MaxVersion: 3},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := encode(&MetadataResponse{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first connection answers its first metadata request with a
+	// correlation ID which was never sent, the others behave
+	var connections, apiVersionsRequests int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			desync := atomic.AddInt32(&connections, 1) == 1
+			go func() {
+				defer conn.Close()
+				for {
+					var length [4]byte
+					if _, err := io.ReadFull(conn, length[:]); err != nil {
+						return
+					}
+					req := make([]byte, binary.BigEndian.Uint32(length[:]))
+					if _, err := io.ReadFull(conn, req); err != nil {
+						return
+					}
+
+					body := metadata
+					isApiVersions := binary.BigEndian.Uint16(req[0:2]) == 18
+					if isApiVersions {
+						atomic.AddInt32(&apiVersionsRequests, 1)
+						body = apiVersions
+					}
+					res := make([]byte, 8, 8+len(body))
+					binary.BigEndian.PutUint32(res[0:], uint32(4+len(body)))
+					copy(res[4:], req[4:8]) // correlation ID
+					if desync && !isApiVersions {
+						res[7] += 100
+						desync = false
+					}
+					res = append(res, body...)
+					if _, err := conn.Write(res); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	conf := NewTestConfig()
+	conf.Version = V2_4_0_0
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	// like the client, open the broker again after each failure
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := broker.GetMetadata(&MetadataRequest{})
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, ErrProtocolDesync) && !errors.Is(err, ErrNotConnected) && !errors.Is(err, net.ErrClosed) {
+					errs <- err
+					return
+				}
+				_ = broker.Open(conf)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_ = broker.Open(conf)
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatalf("expected the request to succeed on a new connection, got %v", err)
+	}
+	if n := atomic.LoadInt32(&connections); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+	if n := atomic.LoadInt32(&apiVersionsRequests); n != 2 {
+		t.Errorf("expected an ApiVersionsRequest on each connection, got %d", n)
+	}
+}
+
+func TestBrokerMatchesResponsesByCorrelationID(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// answers two pipelined produce requests in reverse order, with the
+	// correlation ID of each request times ten as offset
+	serverDone := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverDone <- err
+			return
+		}
+		defer conn.Close()
+
+		var responses [][]byte
+		for i := 0; i < 2; i++ {
+			var length [4]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				serverDone <- err
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(length[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				serverDone <- err
+				return
+			}
+			correlationID := int32(binary.BigEndian.Uint32(req[4:8]))
+
+			res := &ProduceResponse{}
+			res.AddTopicPartition("my_topic", 0, ErrNoError)
+			res.Blocks["my_topic"][0].Offset = int64(correlationID) * 10
+			body, err := encode(res, nil)
+			if err != nil {
+				serverDone <- err
+				return
+			}
+			packet := make([]byte, 8, 8+len(body))
+			binary.BigEndian.PutUint32(packet[0:], uint32(4+len(body)))
+			binary.BigEndian.PutUint32(packet[4:], uint32(correlationID))
+			responses = append(responses, append(packet, body...))
+		}
+		for i := len(responses) - 1; i >= 0; i-- {
+			if _, err := conn.Write(responses[i]); err != nil {
+				serverDone <- err
+				return
+			}
+		}
+		serverDone <- nil
+	}()
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	offsets := make(chan int64, 2)
+	for i := 0; i < 2; i++ {
+		expected := int64(i) * 10
+		req := &ProduceRequest{RequiredAcks: WaitForLocal, Timeout: 100}
+		req.AddMessage("my_topic", 0, &Message{Value: []byte("value")})
+		err := broker.AsyncProduce(req, func(res *ProduceResponse, err error) {
+			if err != nil {
+				t.Errorf("request %d failed: %v", expected/10, err)
+				offsets <- -1
+				return
+			}
+			if offset := res.GetBlock("my_topic", 0).Offset; offset != expected {
+				t.Errorf("request %d got the response with offset %d", expected/10, offset)
+			}
+			offsets <- expected
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-offsets:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the responses")
+		}
+	}
+	if err := <-serverDone; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBrokerSendAndReceiveOutcomes ensures that callers of sendAndReceive can
+// tell a request without response, a transport error and a response which
+// failed to decode apart.
+func TestBrokerSendAndReceiveOutcomes(t *testing.T) {
+	// serve answers every request with the given body, or closes the
+	// connection without answering if it is nil
+	serve := func(t *testing.T, body []byte) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = ln.Close() })
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				var length [4]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				req := make([]byte, binary.BigEndian.Uint32(length[:]))
+				if _, err := io.ReadFull(conn, req); err != nil {
+					return
+				}
+				if body == nil {
+					return
+				}
+				res := make([]byte, 8, 8+len(body))
+				binary.BigEndian.PutUint32(res[0:], uint32(4+len(body)))
+				copy(res[4:], req[4:8]) // correlation ID
+				if _, err := conn.Write(append(res, body...)); err != nil {
+					return
+				}
+			}
+		}()
+		return ln.Addr().String()
+	}
+	open := func(t *testing.T, addr string) *Broker {
+		conf := NewTestConfig()
+		conf.ApiVersionsRequest = false
+		broker := NewBroker(addr)
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = broker.Close() })
+		return broker
+	}
+
+	t.Run("no response expected", func(t *testing.T) {
+		broker := open(t, serve(t, nil))
+		if err := broker.sendAndReceive(&ProduceRequest{RequiredAcks: NoResponse}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		broker := open(t, serve(t, nil))
+		err := broker.sendAndReceive(&MetadataRequest{}, new(MetadataResponse))
+		if err == nil {
+			t.Fatal("expected an error when the connection is closed")
+		}
+		var decodingErr ResponseDecodingError
+		if errors.As(err, &decodingErr) {
+			t.Fatalf("expected a transport error, got %v", err)
+		}
+	})
+
+	t.Run("decoding error", func(t *testing.T) {
+		full := new(MetadataResponse)
+		full.AddBroker("localhost:9092", 1)
+		full.AddTopic("my_topic", ErrNoError)
+		body, err := encode(full, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// cut the response off in the middle of the topics
+		broker := open(t, serve(t, body[:len(body)-2]))
+
+		res := new(MetadataResponse)
+		err = broker.sendAndReceive(&MetadataRequest{}, res)
+		var decodingErr ResponseDecodingError
+		if !errors.As(err, &decodingErr) {
+			t.Fatalf("expected a ResponseDecodingError, got %v", err)
+		}
+		if !errors.Is(err, ErrInsufficientData) {
+			t.Errorf("expected the decoding error to wrap ErrInsufficientData, got %v", err)
+		}
+		if len(res.Brokers) != 1 {
+			t.Errorf("expected the brokers of the partial response, got %v", res.Brokers)
+		}
+	})
+}
+
+func TestBrokerRestrictsRequestVersionsToApiVersions(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"ApiVersionsRequest": NewMockApiVersionsResponse(t).SetApiKeys([]ApiVersionsResponseKey{
+			{ApiKey: 2, MinVersion: 0, MaxVersion: 1},
+			{ApiKey: 3, MinVersion: 0, MaxVersion: 1},
+			{ApiKey: 11, MinVersion: 0, MaxVersion: 4},
+			{ApiKey: 16, MinVersion: 1, MaxVersion: 2},
+		}),
+		"MetadataRequest": NewMockMetadataResponse(t).SetBroker(mb.Addr(), mb.BrokerID()),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V2_8_0_0
+	conf.ApiVersionsRequest = true
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	// newer than the broker supports, with a flexible response header
+	metadata, err := broker.GetMetadata(&MetadataRequest{Version: 9, AllowAutoTopicCreation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Version != 1 {
+		t.Errorf("expected a v1 metadata response, got v%d", metadata.Version)
+	}
+
+	// lowering would let the broker auto-create the topics
+	noAutoCreate := &MetadataRequest{Version: 9, Topics: []string{"my_topic"}}
+	if _, err := broker.GetMetadata(noAutoCreate); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for Metadata v9 without auto-creation, got %v", err)
+	}
+	if noAutoCreate.Version != 9 {
+		t.Errorf("expected the Metadata request to stay at v9, got v%d", noAutoCreate.Version)
+	}
+
+	// lowering would return the high water mark instead of the last stable offset
+	readCommitted := &OffsetRequest{Version: 2, IsolationLevel: ReadCommitted}
+	readCommitted.AddBlock("my_topic", 0, OffsetNewest, 1)
+	if _, err := broker.GetAvailableOffsets(readCommitted); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for a read committed Offset v2, got %v", err)
+	}
+
+	// older than the broker supports, the body may lack what v1 needs
+	if _, err := broker.ListGroups(&ListGroupsRequest{Version: 0}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for ListGroups v0, got %v", err)
+	}
+
+	// the body does not tell which versions can encode its fields
+	if _, err := broker.ListGroups(&ListGroupsRequest{Version: 3}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for ListGroups v3, got %v", err)
+	}
+
+	// lowering would drop the group instance ID of static membership
+	instanceID := "instance"
+	join := &JoinGroupRequest{Version: 5, GroupId: "my_group", GroupInstanceId: &instanceID}
+	if _, err := broker.JoinGroup(join); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for JoinGroup v5 with a group instance ID, got %v", err)
+	}
+	if join.Version != 5 {
+		t.Errorf("expected the JoinGroup request to stay at v5, got v%d", join.Version)
+	}
+
+	// a raw body cannot be re-encoded for another version
+	if _, err := broker.SendRaw(3, 9, nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion for a raw Metadata v9, got %v", err)
+	}
+
+	var versions []int16
+	for _, rr := range mb.History() {
+		versions = append(versions, rr.Request.version())
+	}
+	if !reflect.DeepEqual(versions, []int16{3, 1}) {
+		t.Errorf("expected requests ApiVersions v3 and Metadata v1 only, got versions %v", versions)
+	}
+}
+
+func TestBrokerFetchLenientDecompression(t *testing.T) {
+	records := []*Record{{Value: []byte("hello lenient decompression")}}
+	raw, err := encode(recordsArray(records), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the attributes claim gzip but the payload is snappy
+	batch := &RecordBatch{
+		Version:        2,
+		Codec:          CompressionGZIP,
+		FirstTimestamp: time.Unix(1479847795, 0),
+		MaxTimestamp:   time.Unix(1479847795, 0),
+		Records:        records,
+	}
+	if batch.compressedRecords, err = compress(CompressionSnappy, CompressionLevelDefault, raw); err != nil {
+		t.Fatal(err)
+	}
+	fetchResponse := &FetchResponse{Version: 4}
+	recordsSet := newDefaultRecords(batch)
+	fetchResponse.getOrCreateBlock("my_topic", 0).RecordsSet = []*Records{&recordsSet}
+
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	for _, lenient := range []bool{false, true} {
+		conf := NewTestConfig()
+		conf.Version = V0_11_0_0
+		conf.Consumer.LenientDecompression = lenient
+		broker := NewBroker(mb.Addr())
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+
+		request := &FetchRequest{Version: 4}
+		request.AddBlock("my_topic", 0, 0, 1024, -1)
+		response, err := broker.Fetch(request)
+		if !lenient {
+			if !errors.As(err, new(ResponseDecodingError)) {
+				t.Errorf("expected strict decoding of a mislabelled batch to fail, got %v", err)
+			}
+		} else if err != nil {
+			t.Error(err)
+		} else if value := response.GetBlock("my_topic", 0).RecordsSet[0].RecordBatch.Records[0].Value; string(value) != "hello lenient decompression" {
+			t.Errorf("unexpected record value %q", value)
+		}
+		_ = broker.Close()
+	}
+}
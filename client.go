@@ -123,6 +123,20 @@ type Client interface {
 	Closed() bool
 }
 
+// BrokerPinger is a Client which can also check the health of its brokers. The
+// Client returned by NewClient implements it, type-assert it to use Ping. It is
+// kept out of Client so that other implementations of that interface are not
+// broken.
+type BrokerPinger interface {
+	Client
+
+	// Ping sends a metadata request without topics to every known broker in
+	// parallel and returns the outcome by broker ID: nil for a healthy broker,
+	// otherwise the error encountered or ErrPingTimeout if the broker did not
+	// answer within the timeout.
+	Ping(timeout time.Duration) map[int32]error
+}
+
 const (
 	// OffsetNewest stands for the log head offset, i.e. the offset that will be
 	// assigned to the next message that will be produced to the partition. You
@@ -158,6 +172,10 @@ type client struct {
 	coordinators            map[string]int32                        // Maps consumer group names to coordinating broker IDs
 	transactionCoordinators map[string]int32                        // Maps transaction ids to coordinating broker IDs
 
+	// dedicated connections by purpose to the registered brokers, used with
+	// Net.SeparateConnections
+	dedicatedBrokers map[*Broker]map[connectionPurpose]*Broker
+
 	// If the number of partitions is large, we can get some churn calling cachedPartitions,
 	// so the result is cached.  It is important to update this value whenever metadata is changed
 	cachedPartitionsResults map[string][maxPartitionIndex][]int32
@@ -316,7 +334,14 @@ func (client *client) Close() error {
 		safeAsyncClose(broker)
 	}
 
+	for _, dedicated := range client.dedicatedBrokers {
+		for _, broker := range dedicated {
+			safeAsyncClose(broker)
+		}
+	}
+
 	client.brokers = nil
+	client.dedicatedBrokers = nil
 	client.metadata = nil
 	client.metadataTopics = nil
 
@@ -724,6 +749,7 @@ func (client *client) updateBroker(brokers []*Broker) {
 	currentBroker := make(map[int32]*Broker, len(brokers))
 
 	for _, broker := range brokers {
+		mapBrokerAddress(client.conf, broker)
 		currentBroker[broker.ID()] = broker
 		if client.brokers[broker.ID()] == nil { // add new broker
 			client.brokers[broker.ID()] = broker
@@ -753,6 +779,7 @@ func (client *client) registerBroker(broker *Broker) {
 		return
 	}
 
+	mapBrokerAddress(client.conf, broker)
 	if client.brokers[broker.ID()] == nil {
 		client.brokers[broker.ID()] = broker
 		DebugLogger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
@@ -763,6 +790,29 @@ func (client *client) registerBroker(broker *Broker) {
 	}
 }
 
+// mapBrokerAddress rewrites the advertised address of a broker received in a
+// Metadata, Coordinator or DescribeCluster response using the configured
+// Net.AddressMapper.
+func mapBrokerAddress(conf *Config, broker *Broker) {
+	if conf.Net.AddressMapper == nil {
+		return
+	}
+	if mapped := conf.Net.AddressMapper(broker.addr); mapped != broker.addr {
+		DebugLogger.Printf("client/brokers mapped advertised address %s of broker #%d to %s", broker.addr, broker.ID(), mapped)
+		broker.addr = mapped
+	}
+}
+
+// isRegisteredBroker returns true if the broker is one of those learnt from
+// cluster metadata, as opposed to a seed broker.
+func (client *client) isRegisteredBroker(broker *Broker) bool {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	registered, ok := client.brokers[broker.ID()]
+	return ok && registered == broker
+}
+
 // deregisterBroker removes a broker from the broker list, and if it's
 // not in the broker list, removes it from seedBrokers.
 func (client *client) deregisterBroker(broker *Broker) {
@@ -793,12 +843,21 @@ func (client *client) resurrectDeadBrokers() {
 // LeastLoadedBroker returns the broker with the least pending requests.
 // Firstly, choose the broker from cached broker list. If the broker list is empty, choose from seed brokers.
 func (client *client) LeastLoadedBroker() *Broker {
+	return client.leastLoadedBrokerExcept(nil)
+}
+
+// leastLoadedBrokerExcept is LeastLoadedBroker ignoring the registered brokers
+// in skip. It only falls back to the seed brokers when skip is empty.
+func (client *client) leastLoadedBrokerExcept(skip map[*Broker]none) *Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
 
 	var leastLoadedBroker *Broker
 	pendingRequests := math.MaxInt
 	for _, broker := range client.brokers {
+		if _, ok := skip[broker]; ok {
+			continue
+		}
 		if pendingRequests > broker.ResponseSize() {
 			pendingRequests = broker.ResponseSize()
 			leastLoadedBroker = broker
@@ -809,7 +868,7 @@ func (client *client) LeastLoadedBroker() *Broker {
 		return leastLoadedBroker
 	}
 
-	if len(client.seedBrokers) > 0 {
+	if len(client.seedBrokers) > 0 && len(skip) == 0 {
 		_ = client.seedBrokers[0].Open(client.conf)
 		return client.seedBrokers[0]
 	}
@@ -817,6 +876,107 @@ func (client *client) LeastLoadedBroker() *Broker {
 	return leastLoadedBroker
 }
 
+func (client *client) Ping(timeout time.Duration) map[int32]error {
+	brokers := client.Brokers()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	status := make(map[int32]error, len(brokers))
+	for _, broker := range brokers {
+		wg.Add(1)
+		go func(broker *Broker) {
+			defer wg.Done()
+			err := client.ping(broker, timeout)
+			lock.Lock()
+			status[broker.ID()] = err
+			lock.Unlock()
+		}(broker)
+	}
+	wg.Wait()
+
+	return status
+}
+
+func (client *client) ping(broker *Broker, timeout time.Duration) error {
+	// buffered so that a late answer does not leak the goroutine
+	result := make(chan error, 1)
+	go func() {
+		if err := broker.Open(client.conf); err != nil && !errors.Is(err, ErrAlreadyConnected) {
+			result <- err
+			return
+		}
+		_, err := broker.GetMetadata(NewMetadataRequest(client.conf.Version, []string{}))
+		result <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrPingTimeout
+	}
+}
+
+// connectionPurpose identifies what a dedicated broker connection is used for.
+type connectionPurpose int
+
+const (
+	produceConnection connectionPurpose = iota
+	fetchConnection
+)
+
+// dedicatedBrokerProvider is implemented by clients which can open dedicated
+// connections to a broker, see Net.SeparateConnections.
+type dedicatedBrokerProvider interface {
+	dedicatedBroker(broker *Broker, purpose connectionPurpose) *Broker
+}
+
+// dedicatedBroker returns an opened connection to the same broker reserved
+// for the purpose when Net.SeparateConnections is enabled, and the broker
+// itself otherwise or if it is not a registered broker.
+func (client *client) dedicatedBroker(broker *Broker, purpose connectionPurpose) *Broker {
+	if !client.conf.Net.SeparateConnections {
+		return broker
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.brokers == nil || client.brokers[broker.ID()] != broker {
+		return broker
+	}
+
+	dedicated := client.dedicatedBrokers[broker][purpose]
+	if dedicated == nil {
+		// drop the connections of brokers which were replaced or removed
+		for registered, stale := range client.dedicatedBrokers {
+			if client.brokers[registered.ID()] != registered {
+				for _, b := range stale {
+					safeAsyncClose(b)
+				}
+				delete(client.dedicatedBrokers, registered)
+			}
+		}
+
+		dedicated = NewBroker(broker.Addr())
+		dedicated.id = broker.id
+		dedicated.rack = broker.rack
+		if client.dedicatedBrokers == nil {
+			client.dedicatedBrokers = make(map[*Broker]map[connectionPurpose]*Broker)
+		}
+		if client.dedicatedBrokers[broker] == nil {
+			client.dedicatedBrokers[broker] = make(map[connectionPurpose]*Broker)
+		}
+		client.dedicatedBrokers[broker][purpose] = dedicated
+		DebugLogger.Printf("client/brokers opened dedicated connection %d to broker #%d at %s", purpose, broker.ID(), broker.Addr())
+	}
+	_ = dedicated.Open(client.conf)
+
+	return dedicated
+}
+
 // private caching/lazy metadata helpers
 
 type partitionType int
@@ -863,7 +1023,9 @@ func (client *client) setPartitionCache(topic string, partitionSet partitionType
 
 	ret := make([]int32, 0, len(partitions))
 	for _, partition := range partitions {
-		if partitionSet == writablePartitions && errors.Is(partition.Err, ErrLeaderNotAvailable) {
+		// a partition without a leader (reported either as an error or as
+		// leader -1) is offline and cannot accept writes
+		if partitionSet == writablePartitions && (errors.Is(partition.Err, ErrLeaderNotAvailable) || partition.Leader < 0) {
 			continue
 		}
 		ret = append(ret, partition.ID)
@@ -896,6 +1058,32 @@ func (client *client) cachedLeader(topic string, partitionID int32) (*Broker, in
 	return nil, -1, ErrUnknownTopicOrPartition
 }
 
+// cachedMetadataProvider is implemented by clients which can tell the writable
+// partitions of a topic and their leaders from their cached metadata, without
+// ever refreshing it.
+type cachedMetadataProvider interface {
+	cachedWritablePartitions(topic string) []int32
+	cachedLeaderID(topic string, partitionID int32) (int32, bool)
+}
+
+// cachedWritablePartitions is WritablePartitions without the refresh.
+func (client *client) cachedWritablePartitions(topic string) []int32 {
+	return client.cachedPartitions(topic, writablePartitions)
+}
+
+// cachedLeaderID returns the ID of the cached leader of the partition, and
+// false if the metadata holds no available leader for it.
+func (client *client) cachedLeaderID(topic string, partitionID int32) (int32, bool) {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	metadata, ok := client.metadata[topic][partitionID]
+	if !ok || errors.Is(metadata.Err, ErrLeaderNotAvailable) || client.brokers[metadata.Leader] == nil {
+		return -1, false
+	}
+	return metadata.Leader, true
+}
+
 func (client *client) getOffset(topic string, partitionID int32, timestamp int64) (int64, error) {
 	broker, err := client.Leader(topic, partitionID)
 	if err != nil {
@@ -1016,9 +1204,11 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		return err
 	}
 
+	// registered brokers which returned an empty broker list during this pass
+	emptyBrokers := make(map[*Broker]none)
 	broker := client.LeastLoadedBroker()
 	brokerErrors := make([]error, 0)
-	for ; broker != nil && !pastDeadline(0); broker = client.LeastLoadedBroker() {
+	for ; broker != nil && !pastDeadline(0); broker = client.leastLoadedBrokerExcept(emptyBrokers) {
 		allowAutoTopicCreation := client.conf.Metadata.AllowAutoTopicCreation
 		if len(topics) > 0 {
 			DebugLogger.Printf("client/metadata fetching metadata for %v from broker %s\n", topics, broker.addr)
@@ -1035,9 +1225,14 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		var kerror KError
 		var packetEncodingError PacketEncodingError
 		if err == nil {
-			// When talking to the startup phase of a broker, it is possible to receive an empty metadata set. We should remove that broker and try next broker (https://issues.apache.org/jira/browse/KAFKA-7924).
+			// When talking to the startup phase of a broker, it is possible to receive an empty metadata set. We should try the next broker (https://issues.apache.org/jira/browse/KAFKA-7924).
+			// A registered broker is kept, as the cluster may only be in a transient state, whereas a seed broker is removed.
 			if len(response.Brokers) == 0 {
-				Logger.Println("client/metadata receiving empty brokers from the metadata response when requesting the broker #%d at %s", broker.ID(), broker.addr)
+				Logger.Printf("client/metadata receiving empty brokers from the metadata response when requesting the broker #%d at %s\n", broker.ID(), broker.addr)
+				if client.isRegisteredBroker(broker) {
+					emptyBrokers[broker] = none{}
+					continue
+				}
 				_ = broker.Close()
 				client.deregisterBroker(broker)
 				continue
@@ -1083,6 +1278,13 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		return retry(error)
 	}
 
+	if len(emptyBrokers) > 0 {
+		// every broker left returned an empty set, keep serving from the last
+		// known good metadata and try again after backing off
+		Logger.Println("client/metadata no broker returned a broker list")
+		return retry(ErrNoBrokersInMetadata)
+	}
+
 	Logger.Println("client/metadata no available broker to send metadata request to")
 	client.resurrectDeadBrokers()
 	return retry(error)
@@ -1125,7 +1327,7 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 		case ErrNoError:
 			// no-op
 		case ErrInvalidTopic, ErrTopicAuthorizationFailed: // don't retry, don't store partial results
-			err = topic.Err
+			err = authorizationError(topic.Err, topic.Name)
 			continue
 		case ErrUnknownTopicOrPartition: // retry, do not store partial partition results
 			err = topic.Err
@@ -1255,9 +1457,10 @@ func (client *client) findCoordinator(coordinatorKey string, coordinatorType Coo
 			}
 
 			return retry(ErrConsumerCoordinatorNotAvailable)
-		} else if errors.Is(response.Err, ErrGroupAuthorizationFailed) {
-			Logger.Printf("client was not authorized to access group %s while attempting to find coordinator", coordinatorKey)
-			return retry(ErrGroupAuthorizationFailed)
+		} else if response.Err.IsAuthorizationError() {
+			// not retried, the ACLs have to be changed before this can succeed
+			Logger.Printf("client was not authorized to access %s %s while attempting to find coordinator", response.Err.authorizationResourceType(), coordinatorKey)
+			return nil, authorizationError(response.Err, coordinatorKey)
 		} else {
 			return nil, response.Err
 		}
@@ -1329,3 +1532,24 @@ type nopCloserClient struct {
 func (ncc *nopCloserClient) Close() error {
 	return nil
 }
+
+func (ncc *nopCloserClient) cachedWritablePartitions(topic string) []int32 {
+	if provider, ok := ncc.Client.(cachedMetadataProvider); ok {
+		return provider.cachedWritablePartitions(topic)
+	}
+	return nil
+}
+
+func (ncc *nopCloserClient) cachedLeaderID(topic string, partitionID int32) (int32, bool) {
+	if provider, ok := ncc.Client.(cachedMetadataProvider); ok {
+		return provider.cachedLeaderID(topic, partitionID)
+	}
+	return -1, false
+}
+
+func (ncc *nopCloserClient) dedicatedBroker(broker *Broker, purpose connectionPurpose) *Broker {
+	if provider, ok := ncc.Client.(dedicatedBrokerProvider); ok {
+		return provider.dedicatedBroker(broker, purpose)
+	}
+	return broker
+}
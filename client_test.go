@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"sync"
 	"sync/atomic"
@@ -358,6 +359,72 @@ func TestClientGetOffset(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientGetOffsetOldest(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	metadata := new(MetadataResponse)
+	metadata.AddTopicPartition("foo", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	metadata.AddBroker(leader.Addr(), leader.BrokerID())
+	seedBroker.Returns(metadata)
+
+	client, err := NewClient([]string{seedBroker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("foo", 0, OffsetOldest, 7).
+			SetOffset("foo", 0, OffsetNewest, 42),
+	})
+
+	offset, err := client.GetOffset("foo", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 7 {
+		t.Error("Unexpected oldest offset, got ", offset)
+	}
+
+	offset, err = client.GetOffset("foo", 0, OffsetNewest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 42 {
+		t.Error("Unexpected newest offset, got ", offset)
+	}
+}
+
+func TestClientGetOffsetUnknownPartition(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	metadata := new(MetadataResponse)
+	metadata.AddTopicPartition("foo", 0, seedBroker.BrokerID(), nil, nil, nil, ErrNoError)
+	metadata.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockWrapper(metadata),
+	})
+
+	client, err := NewClient([]string{seedBroker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	offset, err := client.GetOffset("foo", 5, OffsetNewest)
+	if !errors.Is(err, ErrUnknownTopicOrPartition) {
+		t.Fatalf("expected ErrUnknownTopicOrPartition, got %v", err)
+	}
+	if offset != -1 {
+		t.Error("Expected offset -1 on error, got ", offset)
+	}
+}
+
 func TestClientReceivingUnknownTopicWithBackoffFunc(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 
@@ -536,6 +603,102 @@ func TestClientRefreshBehaviourWhenEmptyMetadataResponse(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientRetainsBrokersWhenMetadataHasNoBrokers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 5)
+
+	metadataResponse1 := new(MetadataResponse)
+	metadataResponse1.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse1.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse1)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cluster transiently reports no brokers at all
+	leader.Returns(new(MetadataResponse))
+	if err := c.RefreshMetadata(); !errors.Is(err, ErrNoBrokersInMetadata) {
+		t.Errorf("Expected ErrNoBrokersInMetadata, got %v", err)
+	}
+
+	if brokers := c.Brokers(); len(brokers) != 1 || brokers[0].ID() != leader.BrokerID() {
+		t.Fatalf("Expected the last known broker to be retained, got %v", brokers)
+	}
+	partitionLeader, err := c.Leader("my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partitionLeader.ID() != leader.BrokerID() {
+		t.Errorf("Expected leader %d, got %d", leader.BrokerID(), partitionLeader.ID())
+	}
+
+	// Once a non-empty response arrives it is used again
+	metadataResponse2 := new(MetadataResponse)
+	metadataResponse2.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse2.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	leader.Returns(metadataResponse2)
+	if err := c.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	if brokers := c.Brokers(); len(brokers) != 2 {
+		t.Errorf("Expected 2 brokers, got %d", len(brokers))
+	}
+
+	leader.Close()
+	seedBroker.Close()
+	safeClose(t, c)
+}
+
+func TestClientTriesNextBrokerWhenMetadataHasNoBrokers(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	starting := NewMockBroker(t, 2)
+	healthy := NewMockBroker(t, 3)
+
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(starting.Addr(), starting.BrokerID()).
+		SetBroker(healthy.Addr(), healthy.BrokerID()).
+		SetLeader("my_topic", 0, healthy.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": metadata})
+	healthy.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": metadata})
+	// a broker in its startup phase lists no brokers (KAFKA-7924)
+	starting.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": NewMockMetadataResponse(t)})
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	c, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// whichever broker is asked first, the healthy one answers in the same pass
+	for i := 0; i < 10; i++ {
+		if err := c.RefreshMetadata("my_topic"); err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+		if brokers := c.Brokers(); len(brokers) != 2 {
+			t.Fatalf("Expected both brokers to be retained, got %v", brokers)
+		}
+	}
+
+	// once every broker lists no brokers, the metadata is kept and the refresh fails
+	healthy.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": NewMockMetadataResponse(t)})
+	if err := c.RefreshMetadata("my_topic"); !errors.Is(err, ErrNoBrokersInMetadata) {
+		t.Errorf("Expected ErrNoBrokersInMetadata, got %v", err)
+	}
+	if brokers := c.Brokers(); len(brokers) != 2 {
+		t.Errorf("Expected both brokers to be retained, got %v", brokers)
+	}
+
+	starting.Close()
+	healthy.Close()
+	seedBroker.Close()
+	safeClose(t, c)
+}
+
 func TestClientRefreshBehaviour(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader := NewMockBroker(t, 5)
@@ -974,6 +1137,45 @@ func TestClientCoordinatorWithConsumerOffsetsTopic(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientCoordinatorAuthorizationErrorNotRetried(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetError(CoordinatorGroup, "my_group", ErrGroupAuthorizationFailed),
+	})
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 3
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	_, err = client.Coordinator("my_group")
+	var authErr AuthorizationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected an AuthorizationError, got %v", err)
+	}
+	if authErr.Err != ErrGroupAuthorizationFailed || authErr.ResourceType != "group" || authErr.Resource != "my_group" {
+		t.Errorf("unexpected authorization error %v", authErr)
+	}
+
+	var requests int
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*FindCoordinatorRequest); ok {
+			requests++
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the authorization failure not to be retried, sent %d FindCoordinator requests", requests)
+	}
+}
+
 func TestClientCoordinatorChangeWithConsumerOffsetsTopic(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	staleCoordinator := NewMockBroker(t, 2)
@@ -1250,3 +1452,190 @@ func TestMetricsCleanup(t *testing.T) {
 		t.Errorf("excepted 1 metric, found: %v", all)
 	}
 }
+
+func TestClientAddressMapper(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := NewMockBroker(t, 2)
+	defer leader.Close()
+
+	const advertised = "unreachable.invalid:9092"
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(advertised, leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	var mapped []string
+	config := NewTestConfig()
+	config.Net.AddressMapper = func(addr string) string {
+		mapped = append(mapped, addr)
+		if addr == advertised {
+			return leader.Addr()
+		}
+		return addr
+	}
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	if len(mapped) != 1 || mapped[0] != advertised {
+		t.Errorf("expected the advertised address to be mapped, mapped %v", mapped)
+	}
+
+	broker, err := client.Leader("my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.Addr() != leader.Addr() {
+		t.Fatalf("expected leader at mapped address %s, got %s", leader.Addr(), broker.Addr())
+	}
+
+	// the client really connects to the mapped address
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(leader.History()) != 1 {
+		t.Errorf("expected one request on the mapped broker, got %d", len(leader.History()))
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+	healthy := NewMockBroker(t, 2)
+	defer healthy.Close()
+	slow := NewMockBroker(t, 4)
+	defer slow.Close()
+
+	// nothing listens on the address of a closed broker
+	unreachable := NewMockBroker(t, 3)
+	unreachableAddr := unreachable.Addr()
+	unreachable.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(healthy.Addr(), healthy.BrokerID())
+	metadataResponse.AddBroker(unreachableAddr, 3)
+	metadataResponse.AddBroker(slow.Addr(), slow.BrokerID())
+	seedBroker.Returns(metadataResponse)
+
+	healthy.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+	slow.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+	slow.SetLatency(time.Second)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	status := client.(BrokerPinger).Ping(200 * time.Millisecond)
+	if len(status) != 3 {
+		t.Fatalf("expected the status of 3 brokers, got %v", status)
+	}
+	if err := status[healthy.BrokerID()]; err != nil {
+		t.Errorf("expected healthy broker to answer, got %v", err)
+	}
+	if err := status[3]; err == nil {
+		t.Error("expected an error for the unreachable broker")
+	}
+	if err := status[slow.BrokerID()]; !errors.Is(err, ErrPingTimeout) {
+		t.Errorf("expected ErrPingTimeout for the slow broker, got %v", err)
+	}
+}
+
+func TestClientSeparateConnections(t *testing.T) {
+	for _, separate := range []bool{false, true} {
+		separate := separate
+		t.Run(fmt.Sprintf("separate=%v", separate), func(t *testing.T) {
+			leader := NewMockBroker(t, 1)
+			defer leader.Close()
+
+			leader.SetHandlerByMap(map[string]MockResponse{
+				"MetadataRequest": NewMockMetadataResponse(t).
+					SetBroker(leader.Addr(), leader.BrokerID()).
+					SetLeader("my_topic", 0, leader.BrokerID()),
+				"ProduceRequest": NewMockProduceResponse(t).
+					SetError("my_topic", 0, ErrNoError),
+				"OffsetRequest": NewMockOffsetResponse(t).
+					SetOffset("my_topic", 0, OffsetOldest, 0).
+					SetOffset("my_topic", 0, OffsetNewest, 1),
+				"FetchRequest": NewMockFetchResponse(t, 1).
+					SetMessage("my_topic", 0, 0, StringEncoder("foo")),
+			})
+
+			config := NewTestConfig()
+			config.Producer.Return.Successes = true
+			config.Net.SeparateConnections = separate
+			client, err := NewClient([]string{leader.Addr()}, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, client)
+
+			producer, err := NewSyncProducerFromClient(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, producer)
+			if _, _, err := producer.SendMessage(&ProducerMessage{Topic: "my_topic", Value: StringEncoder("foo")}); err != nil {
+				t.Fatal(err)
+			}
+
+			consumer, err := NewConsumerFromClient(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, consumer)
+			pc, err := consumer.ConsumePartition("my_topic", 0, OffsetOldest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer safeClose(t, pc)
+			select {
+			case <-pc.Messages():
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected a message to be fetched")
+			}
+
+			registered, err := client.Broker(leader.BrokerID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var produceBroker *Broker
+			asyncProducer := producer.(*syncProducer).producer
+			asyncProducer.brokerLock.Lock()
+			for broker := range asyncProducer.brokers {
+				produceBroker = broker
+			}
+			asyncProducer.brokerLock.Unlock()
+			fetchBroker := pc.(*partitionConsumer).broker.broker
+
+			if !separate {
+				if produceBroker != registered || fetchBroker != registered {
+					t.Error("expected produce and fetch to share the connection of the client")
+				}
+				return
+			}
+			if produceBroker == registered || fetchBroker == registered || produceBroker == fetchBroker {
+				t.Errorf("expected produce and fetch to use dedicated connections, got %p and %p for broker %p",
+					produceBroker, fetchBroker, registered)
+			}
+			if produceBroker.ID() != leader.BrokerID() || fetchBroker.ID() != leader.BrokerID() {
+				t.Errorf("expected dedicated connections to broker %d, got %d and %d",
+					leader.BrokerID(), produceBroker.ID(), fetchBroker.ID())
+			}
+		})
+	}
+}
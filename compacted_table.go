@@ -0,0 +1,172 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+// CompactedTable builds the latest value of every key of a compacted topic
+// partition, as used by read-through caches. It reads the messages of a
+// PartitionConsumer, typically started at OffsetOldest, in the background:
+// each message replaces the value of its key, and a tombstone (a message
+// with a nil value) deletes it. Messages without a key are ignored.
+//
+// The table keeps being updated until the PartitionConsumer is closed. If the
+// consumer is configured with Consumer.Return.Errors, its errors must still be
+// read by the caller.
+//
+// CompactedTable is safe for concurrent use.
+type CompactedTable struct {
+	pc            PartitionConsumer
+	oldestOffset  int64
+	highWaterMark int64 // of the partition when the table was created
+	pollInterval  time.Duration
+
+	lock   sync.RWMutex
+	values map[string][]byte
+
+	caughtUp     chan struct{}
+	caughtUpOnce sync.Once
+	done         chan struct{}
+}
+
+// deliveredOffsetReporter is implemented by the PartitionConsumer returned by
+// ConsumePartition, which reports how far it consumed the partition even when
+// the last records are never delivered, such as transaction control records.
+type deliveredOffsetReporter interface {
+	deliveredOffset() int64
+}
+
+// NewCompactedTable creates a CompactedTable reading from the given
+// PartitionConsumer of the topic partition. The client is used to get the
+// oldest offset and the high water mark of the partition when the table is
+// created, telling when it has caught up.
+func NewCompactedTable(client Client, topic string, partition int32, pc PartitionConsumer) (*CompactedTable, error) {
+	oldestOffset, err := client.GetOffset(topic, partition, OffsetOldest)
+	if err != nil {
+		return nil, err
+	}
+	highWaterMark, err := client.GetOffset(topic, partition, OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &CompactedTable{
+		pc:            pc,
+		oldestOffset:  oldestOffset,
+		highWaterMark: highWaterMark,
+		pollInterval:  client.Config().Consumer.MaxWaitTime,
+		values:        make(map[string][]byte),
+		caughtUp:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go withRecover(t.run)
+	return t, nil
+}
+
+func (t *CompactedTable) run() {
+	defer close(t.done)
+
+	// nothing is left below the high water mark, either because nothing was
+	// ever written to the partition or because retention deleted all of it
+	caughtUp := t.oldestOffset >= t.highWaterMark
+	if caughtUp {
+		t.markCaughtUp()
+	}
+
+	// the consumer may reach the high water mark without delivering anything,
+	// so how far it consumed is polled while catching up
+	var poll <-chan time.Time
+	if _, ok := t.pc.(deliveredOffsetReporter); ok && !caughtUp {
+		ticker := time.NewTicker(t.pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	next := int64(-1) // offset following the last message applied
+	for {
+		select {
+		case msg, ok := <-t.pc.Messages():
+			if !ok {
+				return
+			}
+			t.apply(msg)
+			next = msg.Offset + 1
+		case <-poll:
+		}
+
+		if !caughtUp && t.reachedHighWaterMark(next) {
+			caughtUp = true
+			poll = nil
+			t.markCaughtUp()
+		}
+	}
+}
+
+// reachedHighWaterMark returns true once every message below the high water
+// mark has been applied, next being the offset following the last one.
+func (t *CompactedTable) reachedHighWaterMark(next int64) bool {
+	if next >= t.highWaterMark {
+		return true
+	}
+	reporter, ok := t.pc.(deliveredOffsetReporter)
+	if !ok {
+		return false
+	}
+	// the messages below the delivered offset were all put on the channel,
+	// once it is empty they have all been applied
+	return reporter.deliveredOffset() >= t.highWaterMark && len(t.pc.Messages()) == 0
+}
+
+func (t *CompactedTable) apply(msg *ConsumerMessage) {
+	if msg.Key == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if msg.Value == nil {
+		delete(t.values, string(msg.Key))
+	} else {
+		t.values[string(msg.Key)] = msg.Value
+	}
+}
+
+func (t *CompactedTable) markCaughtUp() {
+	t.caughtUpOnce.Do(func() { close(t.caughtUp) })
+}
+
+// CaughtUp returns a channel which is closed once the table has read up to
+// the high water mark of the partition, so that it holds the latest value of
+// every key written before it was created.
+func (t *CompactedTable) CaughtUp() <-chan struct{} {
+	return t.caughtUp
+}
+
+// Done returns a channel which is closed once the PartitionConsumer has been
+// closed and the table stops being updated.
+func (t *CompactedTable) Done() <-chan struct{} {
+	return t.done
+}
+
+// Get returns the latest value of the key and whether the key is present.
+func (t *CompactedTable) Get(key string) ([]byte, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	value, ok := t.values[key]
+	return value, ok
+}
+
+// Snapshot returns a copy of the latest value of every key.
+func (t *CompactedTable) Snapshot() map[string][]byte {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	snapshot := make(map[string][]byte, len(t.values))
+	for key, value := range t.values {
+		snapshot[key] = value
+	}
+	return snapshot
+}
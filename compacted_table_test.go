@@ -0,0 +1,185 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type feedPartitionConsumer struct {
+	PartitionConsumer
+	messages chan *ConsumerMessage
+}
+
+func (pc *feedPartitionConsumer) Messages() <-chan *ConsumerMessage {
+	return pc.messages
+}
+
+// newCompactedTableClient returns a client of a broker reporting the oldest
+// and newest offsets of partition 0 of my_topic.
+func newCompactedTableClient(t *testing.T, oldest, newest int64) (*MockBroker, Client) {
+	t.Helper()
+	broker := NewMockBroker(t, 0)
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, oldest).
+			SetOffset("my_topic", 0, OffsetNewest, newest),
+	})
+	client, err := NewClient([]string{broker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return broker, client
+}
+
+func TestCompactedTableAppliesUpdatesAndTombstones(t *testing.T) {
+	broker, client := newCompactedTableClient(t, 0, 5)
+	defer broker.Close()
+	defer safeClose(t, client)
+
+	pc := &feedPartitionConsumer{messages: make(chan *ConsumerMessage)}
+	table, err := NewCompactedTable(client, "my_topic", 0, pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := []*ConsumerMessage{
+		{Offset: 0, Key: []byte("a"), Value: []byte("1")},
+		{Offset: 1, Key: []byte("b"), Value: []byte("2")},
+		{Offset: 2, Key: []byte("a"), Value: []byte("3")},
+		{Offset: 3, Key: nil, Value: []byte("ignored")},
+		{Offset: 4, Key: []byte("b"), Value: nil}, // tombstone
+	}
+	for i, msg := range updates {
+		select {
+		case <-table.CaughtUp():
+			t.Fatalf("expected the table not to be caught up before offset %d", i)
+		default:
+		}
+		pc.messages <- msg
+	}
+
+	select {
+	case <-table.CaughtUp():
+	case <-time.After(time.Second):
+		t.Fatal("expected the table to be caught up at the high water mark")
+	}
+
+	expected := map[string][]byte{"a": []byte("3")}
+	if snapshot := table.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+		t.Errorf("expected %v, got %v", expected, snapshot)
+	}
+	if _, ok := table.Get("b"); ok {
+		t.Error("expected the tombstone to delete key b")
+	}
+
+	// later updates keep being applied
+	pc.messages <- &ConsumerMessage{Offset: 5, Key: []byte("c"), Value: []byte("4")}
+	close(pc.messages)
+	<-table.Done()
+
+	if value, ok := table.Get("c"); !ok || string(value) != "4" {
+		t.Errorf("expected key c to be 4, got %q", value)
+	}
+}
+
+func TestCompactedTableEmptyPartition(t *testing.T) {
+	broker, client := newCompactedTableClient(t, 0, 0)
+	defer broker.Close()
+	defer safeClose(t, client)
+
+	pc := &feedPartitionConsumer{messages: make(chan *ConsumerMessage)}
+	table, err := NewCompactedTable(client, "my_topic", 0, pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(pc.messages)
+
+	select {
+	case <-table.CaughtUp():
+	case <-time.After(time.Second):
+		t.Fatal("expected the table of an empty partition to be caught up")
+	}
+	if n := len(table.Snapshot()); n != 0 {
+		t.Errorf("expected an empty table, got %d keys", n)
+	}
+}
+
+func TestCompactedTableEmptyAfterRetention(t *testing.T) {
+	// retention deleted every message ever written
+	broker, client := newCompactedTableClient(t, 10, 10)
+	defer broker.Close()
+	defer safeClose(t, client)
+
+	pc := &feedPartitionConsumer{messages: make(chan *ConsumerMessage)}
+	table, err := NewCompactedTable(client, "my_topic", 0, pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(pc.messages)
+
+	select {
+	case <-table.CaughtUp():
+	case <-time.After(time.Second):
+		t.Fatal("expected the table of a partition emptied by retention to be caught up")
+	}
+}
+
+func TestCompactedTableTrailingControlRecords(t *testing.T) {
+	broker := NewMockBroker(t, 0)
+	defer broker.Close()
+
+	fetchResponse := &FetchResponse{Version: 5}
+	fetchResponse.AddRecordBatch("my_topic", 0, StringEncoder("a"), StringEncoder("1"), 0, 7, true)
+	fetchResponse.AddRecordBatch("my_topic", 0, StringEncoder("b"), StringEncoder("2"), 1, 7, true)
+	// the transaction is committed by the last record, which is never delivered
+	fetchResponse.AddControlRecord("my_topic", 0, 2, 7, ControlRecordCommit)
+	fetchResponse.Blocks["my_topic"][0].HighWaterMarkOffset = 3
+
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 3),
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_11_0_0
+	config.Consumer.MaxWaitTime = 10 * time.Millisecond
+	client, err := NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+	pc, err := consumer.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, pc)
+
+	table, err := NewCompactedTable(client, "my_topic", 0, pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-table.CaughtUp():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the table to be caught up past the trailing control record")
+	}
+	expected := map[string][]byte{"a": []byte("1"), "b": []byte("2")}
+	if snapshot := table.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+		t.Errorf("expected %v, got %v", expected, snapshot)
+	}
+}
@@ -48,8 +48,11 @@ type Config struct {
 		// All three of the below configurations are similar to the
 		// `socket.timeout.ms` setting in JVM kafka. All of them default
 		// to 30 seconds.
-		DialTimeout  time.Duration // How long to wait for the initial connection.
-		ReadTimeout  time.Duration // How long to wait for a response.
+		DialTimeout time.Duration // How long to wait for the initial connection.
+		// How long to wait for a response. A read which times out fails every
+		// request in flight on the connection, which is closed, and the broker
+		// is connected again by its next Open.
+		ReadTimeout  time.Duration
 		WriteTimeout time.Duration // How long to wait for a transmit.
 
 		// ResolveCanonicalBootstrapServers turns each bootstrap broker address
@@ -61,6 +64,22 @@ type Config struct {
 		// hostnames. Defaults to false.
 		ResolveCanonicalBootstrapServers bool
 
+		// AddressMapper, if set, is applied by the Client to the address of
+		// every broker learnt from metadata and coordinator responses before
+		// connecting to it, and may return a different "host:port" to use.
+		// This helps in NAT or container setups where the advertised
+		// listeners are not reachable from the client. Seed broker addresses
+		// are used as given.
+		AddressMapper func(advertised string) string
+
+		// SeparateConnections makes the Client open dedicated connections to
+		// each broker for producing and for fetching, in addition to the one
+		// used for metadata, group and admin requests. This keeps large fetch
+		// responses from delaying produce acknowledgements sent over the same
+		// connection, at the cost of up to three connections per broker
+		// (default false).
+		SeparateConnections bool
+
 		TLS struct {
 			// Whether or not to use TLS when connecting to the broker
 			// (defaults to false).
@@ -125,9 +144,30 @@ type Config struct {
 			// Whether or not to use proxy when connecting to the broker
 			// (defaults to false).
 			Enable bool
-			// The proxy dialer to use enabled (defaults to nil).
+			// The proxy dialer to use enabled (defaults to nil). It can be any
+			// dialer, such as one tunnelling through SSH, a SOCKS5 dialer from
+			// proxy.SOCKS5, or proxy.FromEnvironment() to follow the ALL_PROXY
+			// and NO_PROXY environment variables.
 			Dialer proxy.Dialer
 		}
+
+		// RateLimit caps the rate at which requests are sent to each broker.
+		// This is enforced client-side and is independent of any broker-side
+		// quotas. A broker keeps the limit of its first Open across
+		// reconnections, and the requests sent while connecting, for SASL and
+		// ApiVersions, are not limited.
+		RateLimit struct {
+			// The maximum number of requests per second to send on a single
+			// broker connection (defaults to 0, meaning unlimited).
+			RequestsPerSecond float64
+			// The number of requests that may be sent back-to-back before the
+			// rate applies (defaults to 1).
+			Burst int
+			// Whether a request exceeding the rate should block until it is
+			// permitted (defaults to true). If disabled, such requests fail
+			// immediately with ErrRequestRateLimited.
+			Block bool
+		}
 	}
 
 	// Metadata is the namespace for metadata management properties used by the
@@ -141,8 +181,8 @@ type Config struct {
 			// (default 250ms). Similar to the JVM's `retry.backoff.ms`.
 			Backoff time.Duration
 			// Called to compute backoff time dynamically. Useful for implementing
-			// more sophisticated backoff strategies. This takes precedence over
-			// `Backoff` if set.
+			// more sophisticated backoff strategies, such as
+			// NewExponentialBackoff. This takes precedence over `Backoff` if set.
 			BackoffFunc func(retries, maxRetries int) time.Duration
 		}
 		// How frequently to refresh the cluster metadata in the background.
@@ -192,10 +232,23 @@ type Config struct {
 		// on the actual compression type used and defaults to default compression
 		// level for the codec.
 		CompressionLevel int
+		// If enabled, the values of large messages are written to the network
+		// from the memory of the messages, as part of a scatter/gather write,
+		// instead of being copied into the produce request (defaults to
+		// false). This only applies when Compression is CompressionNone, as
+		// compressed values have to be copied anyway.
+		ZeroCopy bool
 		// Generates partitioners for choosing the partition to send messages to
 		// (defaults to hashing the message key). Similar to the `partitioner.class`
 		// setting for the JVM producer.
 		Partitioner PartitionerConstructor
+		// If enabled, messages which must be sent to a specific partition (for
+		// example keyed messages with the hash partitioner) fail immediately
+		// with ErrLeaderNotAvailable when that partition has no leader, instead
+		// of being retried until the leader comes back (defaults to false).
+		// Messages which do not require consistency are always routed to
+		// partitions with a leader.
+		FailFastOnOfflinePartition bool
 		// If enabled, the producer will ensure that exactly one copy of each message is
 		// written.
 		Idempotent bool
@@ -278,6 +331,16 @@ type Config struct {
 		// OnSend() is passed to the second interceptor OnSend(), and so on in
 		// the interceptor chain.
 		Interceptors []ProducerInterceptor
+
+		// OnAcknowledgement, if set, is called by the AsyncProducer once for
+		// every partition of a produce response the broker acknowledged
+		// successfully, with the offset assigned to the first message and the
+		// number of messages written. This gives a batch level view of the
+		// throughput, unlike the per message Successes() channel. It is not
+		// called when RequiredAcks is NoResponse, as there is no response to
+		// report on. The function is called from the producer's internal
+		// goroutines and must not block.
+		OnAcknowledgement func(ProducerAcknowledgement)
 	}
 
 	// Consumer is the namespace for configuration related to consuming messages,
@@ -355,9 +418,16 @@ type Config struct {
 			// trying again (default 2s).
 			Backoff time.Duration
 			// Called to compute backoff time dynamically. Useful for implementing
-			// more sophisticated backoff strategies. This takes precedence over
-			// `Backoff` if set.
+			// more sophisticated backoff strategies, such as the one returned by
+			// NewConsumerExponentialBackoff. This takes precedence over `Backoff`
+			// if set.
 			BackoffFunc func(retries int) time.Duration
+			// How long ConsumePartition waits for a partition without a leader,
+			// typically while one is being elected, to get one. Metadata is
+			// refreshed after each backoff until a leader shows up or the wait
+			// is over, at which point ErrLeaderNotAvailable is returned
+			// (default 0, fail straight away).
+			LeaderWait time.Duration
 		}
 
 		// Fetch is the namespace for controlling how many bytes are retrieved by any
@@ -381,7 +451,33 @@ type Config struct {
 			// (no limit). Similar to the JVM's `fetch.message.max.bytes`. The
 			// global `sarama.MaxResponseSize` still applies.
 			Max int32
+			// The number of consecutive fetches of a partition returning no
+			// messages although its high water mark is ahead of the consumer's
+			// offset, after which the consumer refreshes metadata and re-checks
+			// the partition leader before fetching again (default 0, disabled).
+			// This keeps the consumer from polling a broker which silently lost
+			// leadership forever.
+			EmptyFetchesBeforeLeaderCheck int
+			// Session enables KIP-227 incremental fetch sessions (defaults to
+			// false, requires Version >= V1_1_0_0). Once the broker has created
+			// a session, a fetch request only lists the partitions whose fetch
+			// offset or size changed since the previous one, and the partitions
+			// to drop from the session, which keeps the requests small for
+			// consumers of many partitions. Partitions without new data are left
+			// out of the responses.
+			Session bool
 		}
+		// MaxBufferedBytes bounds the total size of the fetch responses buffered
+		// by a consumer across all of its partitions and brokers (defaults to 0,
+		// meaning no limit). Each fetch reserves the partition fetch sizes it
+		// asks for against this budget. The size of each fetched message is
+		// released once it is delivered on the Messages channel, and the rest of
+		// the reservation once the partition is done with the response, so
+		// the memory used stays bounded regardless of the number of partitions.
+		// Partitions which do not fit in the remaining budget are left out of a
+		// fetch until some of the budget is released. Must be at least
+		// Consumer.Fetch.Default when set.
+		MaxBufferedBytes int
 		// The maximum amount of time the broker will wait for Consumer.Fetch.Min
 		// bytes to become available before it returns fewer than that anyways. The
 		// default is 250ms, since 0 causes the consumer to spin when no events are
@@ -455,6 +551,14 @@ type Config struct {
 			}
 		}
 
+		// LenientDecompression makes the consumer tolerate records whose
+		// attributes declare a different compression codec than the one
+		// actually used, as occasionally produced by buggy clients. When
+		// decompressing with the declared codec fails, the real codec is
+		// guessed from the payload's magic bytes and tried before giving up
+		// (defaults to false, meaning the declared codec is trusted).
+		LenientDecompression bool
+
 		// IsolationLevel support 2 mode:
 		// 	- use `ReadUncommitted` (default) to consume and return all messages in message channel
 		//	- use `ReadCommitted` to hide messages that are part of an aborted transaction
@@ -485,7 +589,14 @@ type Config struct {
 	// ApiVersionsRequest determines whether Sarama should send an
 	// ApiVersionsRequest message to each broker as part of its initial
 	// connection. This defaults to `true` to match the official Java client
-	// and most 3rdparty ones.
+	// and most 3rdparty ones. When the broker answers, the requests which
+	// know the versions able to encode their fields, such as Metadata,
+	// Offset, Fetch and Produce, are lowered to the newest version it
+	// supports when they do not set fields the lower version would drop.
+	// Other requests newer than it supports, requests which cannot be
+	// lowered, and requests older than it supports fail with
+	// ErrUnsupportedVersion. Record formats and features are still chosen
+	// from Version. It is only sent when Version is at least V2_4_0_0.
 	ApiVersionsRequest bool
 	// The version of Kafka that Sarama will assume it is running against.
 	// Defaults to the oldest supported stable version. Since Kafka provides
@@ -516,6 +627,8 @@ func NewConfig() *Config {
 	c.Net.WriteTimeout = 30 * time.Second
 	c.Net.SASL.Handshake = true
 	c.Net.SASL.Version = SASLHandshakeV1
+	c.Net.RateLimit.Burst = 1
+	c.Net.RateLimit.Block = true
 
 	c.Metadata.Retry.Max = 3
 	c.Metadata.Retry.Backoff = 250 * time.Millisecond
@@ -614,6 +727,9 @@ func (c *Config) Validate() error {
 	if c.Consumer.Group.Rebalance.Timeout%time.Millisecond != 0 {
 		Logger.Println("Consumer.Group.Rebalance.Timeout only supports millisecond precision; nanoseconds will be truncated.")
 	}
+	if c.Consumer.LenientDecompression {
+		Logger.Println("Consumer.LenientDecompression is enabled; records whose declared codec fails will be decoded with a guessed one.")
+	}
 	if c.ClientID == defaultClientID {
 		Logger.Println("ClientID is the default of 'sarama', you should consider setting it to something application-specific.")
 	}
@@ -628,6 +744,12 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Net.ReadTimeout must be > 0")
 	case c.Net.WriteTimeout <= 0:
 		return ConfigurationError("Net.WriteTimeout must be > 0")
+	case c.Net.Proxy.Enable && c.Net.Proxy.Dialer == nil:
+		return ConfigurationError("Net.Proxy.Dialer must be set when Net.Proxy.Enable is true")
+	case c.Net.RateLimit.RequestsPerSecond < 0:
+		return ConfigurationError("Net.RateLimit.RequestsPerSecond must be >= 0")
+	case c.Net.RateLimit.RequestsPerSecond > 0 && c.Net.RateLimit.Burst <= 0:
+		return ConfigurationError("Net.RateLimit.Burst must be > 0 when rate limiting is enabled")
 	case c.Net.SASL.Enable:
 		if c.Net.SASL.Mechanism == "" {
 			c.Net.SASL.Mechanism = SASLTypePlaintext
@@ -781,12 +903,22 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Consumer.Fetch.Default must be > 0")
 	case c.Consumer.Fetch.Max < 0:
 		return ConfigurationError("Consumer.Fetch.Max must be >= 0")
+	case c.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck < 0:
+		return ConfigurationError("Consumer.Fetch.EmptyFetchesBeforeLeaderCheck must be >= 0")
+	case c.Consumer.Fetch.Session && !c.Version.IsAtLeast(V1_1_0_0):
+		return ConfigurationError("Consumer.Fetch.Session requires Version >= V1_1_0_0")
+	case c.Consumer.MaxBufferedBytes < 0:
+		return ConfigurationError("Consumer.MaxBufferedBytes must be >= 0")
+	case c.Consumer.MaxBufferedBytes > 0 && c.Consumer.MaxBufferedBytes < int(c.Consumer.Fetch.Default):
+		return ConfigurationError("Consumer.MaxBufferedBytes must be >= Consumer.Fetch.Default")
 	case c.Consumer.MaxWaitTime < 1*time.Millisecond:
 		return ConfigurationError("Consumer.MaxWaitTime must be >= 1ms")
 	case c.Consumer.MaxProcessingTime <= 0:
 		return ConfigurationError("Consumer.MaxProcessingTime must be > 0")
 	case c.Consumer.Retry.Backoff < 0:
 		return ConfigurationError("Consumer.Retry.Backoff must be >= 0")
+	case c.Consumer.Retry.LeaderWait < 0:
+		return ConfigurationError("Consumer.Retry.LeaderWait must be >= 0")
 	case c.Consumer.Offsets.AutoCommit.Interval <= 0:
 		return ConfigurationError("Consumer.Offsets.AutoCommit.Interval must be > 0")
 	case c.Consumer.Offsets.Initial != OffsetOldest && c.Consumer.Offsets.Initial != OffsetNewest:
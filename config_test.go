@@ -92,6 +92,28 @@ func TestNetConfigValidates(t *testing.T) {
 			},
 			"Net.WriteTimeout must be > 0",
 		},
+		{
+			"Proxy.Dialer",
+			func(cfg *Config) {
+				cfg.Net.Proxy.Enable = true
+			},
+			"Net.Proxy.Dialer must be set when Net.Proxy.Enable is true",
+		},
+		{
+			"RateLimit.RequestsPerSecond",
+			func(cfg *Config) {
+				cfg.Net.RateLimit.RequestsPerSecond = -1
+			},
+			"Net.RateLimit.RequestsPerSecond must be >= 0",
+		},
+		{
+			"RateLimit.Burst",
+			func(cfg *Config) {
+				cfg.Net.RateLimit.RequestsPerSecond = 10
+				cfg.Net.RateLimit.Burst = 0
+			},
+			"Net.RateLimit.Burst must be > 0 when rate limiting is enabled",
+		},
 		{
 			"SASL.User",
 			func(cfg *Config) {
@@ -486,6 +508,34 @@ func TestConsumerConfigValidates(t *testing.T) {
 			},
 			"Consumer.IsolationLevel must be ReadUncommitted or ReadCommitted",
 		},
+		{
+			"Negative LeaderWait",
+			func(cfg *Config) {
+				cfg.Consumer.Retry.LeaderWait = -1
+			},
+			"Consumer.Retry.LeaderWait must be >= 0",
+		},
+		{
+			"Negative EmptyFetchesBeforeLeaderCheck",
+			func(cfg *Config) {
+				cfg.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck = -1
+			},
+			"Consumer.Fetch.EmptyFetchesBeforeLeaderCheck must be >= 0",
+		},
+		{
+			"Negative MaxBufferedBytes",
+			func(cfg *Config) {
+				cfg.Consumer.MaxBufferedBytes = -1
+			},
+			"Consumer.MaxBufferedBytes must be >= 0",
+		},
+		{
+			"MaxBufferedBytes below the fetch size",
+			func(cfg *Config) {
+				cfg.Consumer.MaxBufferedBytes = int(cfg.Consumer.Fetch.Default) - 1
+			},
+			"Consumer.MaxBufferedBytes must be >= Consumer.Fetch.Default",
+		},
 	}
 
 	for i, test := range tests {
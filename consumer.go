@@ -96,15 +96,34 @@ type Consumer interface {
 	ResumeAll()
 }
 
+// BoundedConsumer is a Consumer which can also stop consuming a partition at an
+// end offset. The Consumer returned by NewConsumer implements it, type-assert it
+// to use ConsumePartitionUntil. It is kept out of Consumer so that other
+// implementations of that interface are not broken.
+type BoundedConsumer interface {
+	Consumer
+
+	// ConsumePartitionUntil creates a PartitionConsumer on the given topic/partition
+	// just like ConsumePartition, except that it stops at endOffset: messages at or
+	// beyond endOffset are never delivered, and the Messages channel is closed as
+	// soon as the consumer reaches it. endOffset must be a literal offset not
+	// smaller than the starting offset.
+	ConsumePartitionUntil(topic string, partition int32, offset, endOffset int64) (PartitionConsumer, error)
+}
+
 // max time to wait for more partition subscriptions
 const partitionConsumersBatchTimeout = 100 * time.Millisecond
 
+// noEndOffset marks a partition consumer that consumes without an upper bound
+const noEndOffset int64 = -1
+
 type consumer struct {
 	conf            *Config
 	children        map[string]map[int32]*partitionConsumer
 	brokerConsumers map[*Broker]*brokerConsumer
 	client          Client
 	metricRegistry  metrics.Registry
+	fetchBudget     *fetchBudget // nil unless Consumer.MaxBufferedBytes is set
 	lock            sync.Mutex
 }
 
@@ -139,11 +158,18 @@ func newConsumer(client Client) (Consumer, error) {
 		brokerConsumers: make(map[*Broker]*brokerConsumer),
 		metricRegistry:  newCleanupRegistry(client.Config().MetricRegistry),
 	}
+	if c.conf.Consumer.MaxBufferedBytes > 0 {
+		c.fetchBudget = newFetchBudget(c.conf.Consumer.MaxBufferedBytes)
+	}
 
 	return c, nil
 }
 
 func (c *consumer) Close() error {
+	if c.fetchBudget != nil {
+		// broker consumers waiting for the budget would otherwise never return
+		c.fetchBudget.close()
+	}
 	c.metricRegistry.UnregisterAll()
 	return c.client.Close()
 }
@@ -157,6 +183,17 @@ func (c *consumer) Partitions(topic string) ([]int32, error) {
 }
 
 func (c *consumer) ConsumePartition(topic string, partition int32, offset int64) (PartitionConsumer, error) {
+	return c.consumePartition(topic, partition, offset, noEndOffset)
+}
+
+func (c *consumer) ConsumePartitionUntil(topic string, partition int32, offset, endOffset int64) (PartitionConsumer, error) {
+	if endOffset < 0 {
+		return nil, ConfigurationError("endOffset must be a literal offset")
+	}
+	return c.consumePartition(topic, partition, offset, endOffset)
+}
+
+func (c *consumer) consumePartition(topic string, partition int32, offset, endOffset int64) (PartitionConsumer, error) {
 	child := &partitionConsumer{
 		consumer:             c,
 		conf:                 c.conf,
@@ -166,16 +203,27 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 		errors:               make(chan *ConsumerError, c.conf.ChannelBufferSize),
 		feeder:               make(chan *FetchResponse, 1),
 		leaderEpoch:          invalidLeaderEpoch,
+		lastRecordEpoch:      invalidLeaderEpoch,
 		preferredReadReplica: invalidPreferredReplicaID,
 		trigger:              make(chan none, 1),
 		dying:                make(chan none),
 		fetchSize:            c.conf.Consumer.Fetch.Default,
+		endOffset:            endOffset,
+	}
+
+	if err := c.waitForLeader(topic, partition); err != nil {
+		return nil, err
 	}
 
 	if err := child.chooseStartingOffset(offset); err != nil {
 		return nil, err
 	}
 
+	if endOffset != noEndOffset && endOffset < child.offset {
+		return nil, ConfigurationError("endOffset must not be smaller than the starting offset")
+	}
+	emptyRange := child.reachedEndOffset()
+
 	leader, epoch, err := c.client.LeaderAndEpoch(child.topic, child.partition)
 	if err != nil {
 		return nil, err
@@ -192,9 +240,46 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 	child.broker = c.refBrokerConsumer(leader)
 	child.broker.input <- child
 
+	if emptyRange {
+		// there is nothing to deliver, shut down straight away
+		child.AsyncClose()
+	}
+
 	return child, nil
 }
 
+// waitForLeader waits up to Consumer.Retry.LeaderWait for the partition to
+// have a leader, refreshing metadata after each backoff. Any error other than
+// ErrLeaderNotAvailable is left for the caller to run into.
+func (c *consumer) waitForLeader(topic string, partition int32) error {
+	if c.conf.Consumer.Retry.LeaderWait <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(c.conf.Consumer.Retry.LeaderWait)
+	for retries := 0; ; retries++ {
+		// looking up a missing leader refreshes the metadata of the topic
+		_, err := c.client.Leader(topic, partition)
+		if !errors.Is(err, ErrLeaderNotAvailable) {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return err
+		}
+		backoff := c.conf.Consumer.Retry.Backoff
+		if c.conf.Consumer.Retry.BackoffFunc != nil {
+			backoff = c.conf.Consumer.Retry.BackoffFunc(retries)
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		Logger.Printf("consumer/%s/%d has no leader, retrying in %s\n", topic, partition, backoff)
+		time.Sleep(backoff)
+	}
+}
+
 func (c *consumer) HighWaterMarks() map[string]map[int32]int64 {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -237,6 +322,11 @@ func (c *consumer) removeChild(child *partitionConsumer) {
 }
 
 func (c *consumer) refBrokerConsumer(broker *Broker) *brokerConsumer {
+	// fetch over a dedicated connection with Net.SeparateConnections
+	if provider, ok := c.client.(dedicatedBrokerProvider); ok {
+		broker = provider.dedicatedBroker(broker, fetchConnection)
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -393,6 +483,7 @@ type PartitionConsumer interface {
 
 type partitionConsumer struct {
 	highWaterMarkOffset int64 // must be at the top of the struct because https://golang.org/pkg/sync/atomic/#pkg-note-BUG
+	delivered           int64 // offset up to which every message was put on the messages channel, accessed atomically
 
 	consumer *consumer
 	conf     *Config
@@ -402,6 +493,7 @@ type partitionConsumer struct {
 	feeder   chan *FetchResponse
 
 	leaderEpoch          int32
+	lastRecordEpoch      int32 // leader epoch of the last consumed record batch
 	preferredReadReplica int32
 
 	trigger, dying chan none
@@ -411,13 +503,18 @@ type partitionConsumer struct {
 	responseResult error
 	fetchSize      int32
 	offset         int64
+	endOffset      int64
 	retries        int32
+	emptyFetches   int // consecutive empty fetches while behind the high water mark
+	reservedBytes  int // share of the consumer's fetch budget held for the response being fed
 
 	paused int32
 }
 
 var errTimedOut = errors.New("timed out feeding messages to the user") // not user-facing
 
+var errEmptyFetchesBehind = errors.New("repeatedly fetched no messages while behind the high water mark") // not user-facing
+
 func (child *partitionConsumer) sendError(err error) {
 	cErr := &ConsumerError{
 		Topic:     child.topic,
@@ -453,6 +550,12 @@ func (child *partitionConsumer) dispatcher() {
 
 			if err := child.dispatch(); err != nil {
 				child.sendError(err)
+				if errors.As(err, new(LogTruncationError)) {
+					// consuming on would skip the divergent records, let the user choose
+					Logger.Printf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, err)
+					close(child.trigger)
+					continue
+				}
 				child.trigger <- none{}
 			}
 		}
@@ -495,6 +598,10 @@ func (child *partitionConsumer) dispatch() error {
 		return err
 	}
 
+	if err := child.checkTruncation(epoch); err != nil {
+		return err
+	}
+
 	child.leaderEpoch = epoch
 	child.broker = child.consumer.refBrokerConsumer(broker)
 	child.broker.input <- child
@@ -502,6 +609,52 @@ func (child *partitionConsumer) dispatch() error {
 	return nil
 }
 
+// checkTruncation asks the leader, once its epoch is past the one of the last
+// consumed records, where that epoch ends in its log (KIP-320). An end offset
+// below the offset to consume means that the log was truncated, and that the
+// records consumed past the end offset diverged from the log of the leader.
+func (child *partitionConsumer) checkTruncation(epoch int32) error {
+	if !child.conf.Version.IsAtLeast(V2_1_0_0) || child.lastRecordEpoch < 0 || epoch <= child.lastRecordEpoch {
+		return nil
+	}
+
+	leader, err := child.consumer.client.Leader(child.topic, child.partition)
+	if err != nil {
+		return err
+	}
+
+	request := &OffsetForLeaderEpochRequest{Version: 2}
+	if child.conf.Version.IsAtLeast(V2_3_0_0) {
+		request.Version = 3
+		request.ReplicaID = -1
+	}
+	request.AddBlock(child.topic, child.partition, epoch, child.lastRecordEpoch)
+
+	response, err := leader.OffsetForLeaderEpoch(request)
+	if err != nil {
+		return err
+	}
+	block := response.GetBlock(child.topic, child.partition)
+	if block == nil {
+		return ErrIncompleteResponse
+	}
+	if !errors.Is(block.Err, ErrNoError) {
+		return block.Err
+	}
+
+	if block.EndOffset >= 0 && block.EndOffset < child.offset {
+		return LogTruncationError{
+			Topic:     child.topic,
+			Partition: child.partition,
+			Offset:    child.offset,
+			EndOffset: block.EndOffset,
+		}
+	}
+	// the records up to the offset to consume are in the log of the leader
+	child.lastRecordEpoch = epoch
+	return nil
+}
+
 func (child *partitionConsumer) chooseStartingOffset(offset int64) error {
 	newestOffset, err := child.consumer.client.GetOffset(child.topic, child.partition, OffsetNewest)
 	if err != nil {
@@ -525,10 +678,23 @@ func (child *partitionConsumer) chooseStartingOffset(offset int64) error {
 	default:
 		return ErrOffsetOutOfRange
 	}
+	child.delivered = child.offset
 
 	return nil
 }
 
+// reachedEndOffset returns true once a consumer created with an end offset has
+// consumed everything before it.
+func (child *partitionConsumer) reachedEndOffset() bool {
+	return child.endOffset != noEndOffset && child.offset >= child.endOffset
+}
+
+// beyondEndOffset returns true if the message at offset must not be delivered
+// because it is at or past the end offset.
+func (child *partitionConsumer) beyondEndOffset(offset int64) bool {
+	return child.endOffset != noEndOffset && offset >= child.endOffset
+}
+
 func (child *partitionConsumer) Messages() <-chan *ConsumerMessage {
 	return child.messages
 }
@@ -565,6 +731,13 @@ func (child *partitionConsumer) HighWaterMarkOffset() int64 {
 	return atomic.LoadInt64(&child.highWaterMarkOffset)
 }
 
+// deliveredOffset returns the offset up to which the partition was consumed and
+// every message put on the Messages channel, including past the records which
+// are never delivered such as control records.
+func (child *partitionConsumer) deliveredOffset() int64 {
+	return atomic.LoadInt64(&child.delivered)
+}
+
 func (child *partitionConsumer) responseFeeder() {
 	var msgs []*ConsumerMessage
 	expiryTicker := time.NewTicker(child.conf.Consumer.MaxProcessingTime)
@@ -583,13 +756,16 @@ feederLoop:
 		messageSelect:
 			select {
 			case <-child.dying:
+				child.releaseFetchBudget(child.reservedBytes)
 				child.broker.acks.Done()
 				continue feederLoop
 			case child.messages <- msg:
 				firstAttempt = true
+				child.releaseFetchBudget(len(msg.Key) + len(msg.Value))
 			case <-expiryTicker.C:
 				if !firstAttempt {
 					child.responseResult = errTimedOut
+					child.releaseFetchBudget(child.reservedBytes)
 					child.broker.acks.Done()
 				remainingLoop:
 					for _, msg = range msgs[i:] {
@@ -600,6 +776,9 @@ feederLoop:
 							break remainingLoop
 						}
 					}
+					if child.reachedEndOffset() {
+						child.AsyncClose()
+					}
 					child.broker.input <- child
 					continue feederLoop
 				} else {
@@ -611,7 +790,13 @@ feederLoop:
 			}
 		}
 
+		atomic.StoreInt64(&child.delivered, child.offset)
+		child.releaseFetchBudget(child.reservedBytes)
 		child.broker.acks.Done()
+
+		if child.reachedEndOffset() {
+			child.AsyncClose()
+		}
 	}
 
 	expiryTicker.Stop()
@@ -619,6 +804,20 @@ feederLoop:
 	close(child.errors)
 }
 
+// releaseFetchBudget gives up to n bytes of the share of the consumer's fetch
+// budget held for the response being fed back to the budget. The size of each
+// message is released once it is delivered, and the rest of the share once the
+// response is done with.
+func (child *partitionConsumer) releaseFetchBudget(n int) {
+	if n > child.reservedBytes {
+		n = child.reservedBytes
+	}
+	child.reservedBytes -= n
+	if child.consumer.fetchBudget != nil {
+		child.consumer.fetchBudget.release(n)
+	}
+}
+
 func (child *partitionConsumer) parseMessages(msgSet *MessageSet) ([]*ConsumerMessage, error) {
 	var messages []*ConsumerMessage
 	for _, msgBlock := range msgSet.Messages {
@@ -635,6 +834,10 @@ func (child *partitionConsumer) parseMessages(msgSet *MessageSet) ([]*ConsumerMe
 			if offset < child.offset {
 				continue
 			}
+			if child.beyondEndOffset(offset) {
+				child.offset = child.endOffset
+				return messages, nil
+			}
 			messages = append(messages, &ConsumerMessage{
 				Topic:          child.topic,
 				Partition:      child.partition,
@@ -661,6 +864,10 @@ func (child *partitionConsumer) parseRecords(batch *RecordBatch) ([]*ConsumerMes
 		if offset < child.offset {
 			continue
 		}
+		if child.beyondEndOffset(offset) {
+			child.offset = child.endOffset
+			return messages, nil
+		}
 		timestamp := batch.FirstTimestamp.Add(rec.TimestampDelta)
 		if batch.LogAppendTime {
 			timestamp = batch.MaxTimestamp
@@ -702,7 +909,7 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 	}
 
 	if !errors.Is(block.Err, ErrNoError) {
-		return nil, block.Err
+		return nil, authorizationError(block.Err, child.topic)
 	}
 
 	nRecs, err := block.numRecords()
@@ -719,6 +926,9 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 	}
 
 	if nRecs == 0 {
+		if block.Corrupt != nil {
+			return nil, *block.Corrupt
+		}
 		partialTrailingMessage, err := block.isPartial()
 		if err != nil {
 			return nil, err
@@ -744,6 +954,16 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 			// check last record offset to avoid stuck if high watermark was not reached
 			Logger.Printf("consumer/broker/%d received batch with zero records but high watermark was not reached, topic %s, partition %d, offset %d\n", child.broker.broker.ID(), child.topic, child.partition, *block.LastRecordsBatchOffset)
 			child.offset = *block.LastRecordsBatchOffset + 1
+			child.emptyFetches = 0
+		} else if block.HighWaterMarkOffset > child.offset {
+			// there should have been messages, the broker may no longer be the leader
+			child.emptyFetches++
+			if limit := child.conf.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck; limit > 0 && child.emptyFetches >= limit {
+				child.emptyFetches = 0
+				return nil, errEmptyFetchesBehind
+			}
+		} else {
+			child.emptyFetches = 0
 		}
 
 		return nil, nil
@@ -751,6 +971,7 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 
 	// we got messages, reset our fetch size in case it was increased for a previous request
 	child.fetchSize = child.conf.Consumer.Fetch.Default
+	child.emptyFetches = 0
 	atomic.StoreInt64(&child.highWaterMarkOffset, block.HighWaterMarkOffset)
 
 	// abortedProducerIDs contains producerID which message should be ignored as uncommitted
@@ -784,6 +1005,7 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 			if err != nil {
 				return nil, err
 			}
+			child.lastRecordEpoch = records.RecordBatch.PartitionLeaderEpoch
 
 			// Parse and commit offset but do not expose messages that are:
 			// - control records
@@ -858,6 +1080,7 @@ type brokerConsumer struct {
 	subscriptions    map[*partitionConsumer]none
 	acks             sync.WaitGroup
 	refs             int
+	session          *fetchSession // nil unless Consumer.Fetch.Session is enabled
 }
 
 func (c *consumer) newBrokerConsumer(broker *Broker) *brokerConsumer {
@@ -869,6 +1092,9 @@ func (c *consumer) newBrokerConsumer(broker *Broker) *brokerConsumer {
 		subscriptions:    make(map[*partitionConsumer]none),
 		refs:             0,
 	}
+	if c.conf.Consumer.Fetch.Session {
+		bc.session = new(fetchSession)
+	}
 
 	go withRecover(bc.subscriptionManager)
 	go withRecover(bc.subscriptionConsumer)
@@ -934,6 +1160,7 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 
 		response, err := bc.fetchNewMessages()
 		if err != nil {
+			bc.releaseFetchBudget()
 			Logger.Printf("consumer/broker/%d disconnecting due to error processing FetchRequest: %s\n", bc.broker.ID(), err)
 			bc.abort(err)
 			return
@@ -942,6 +1169,7 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 		// if there isn't response, it means that not fetch was made
 		// so we don't need to handle any response
 		if response == nil {
+			bc.releaseFetchBudget()
 			time.Sleep(partitionConsumersBatchTimeout)
 			continue
 		}
@@ -949,15 +1177,19 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 		bc.acks.Add(len(bc.subscriptions))
 		for child := range bc.subscriptions {
 			if _, ok := response.Blocks[child.topic]; !ok {
+				child.releaseFetchBudget(child.reservedBytes)
 				bc.acks.Done()
 				continue
 			}
 
 			if _, ok := response.Blocks[child.topic][child.partition]; !ok {
+				child.releaseFetchBudget(child.reservedBytes)
 				bc.acks.Done()
 				continue
 			}
 
+			// the child releases its share of the fetch budget as it
+			// delivers the messages
 			child.feeder <- response
 		}
 		bc.acks.Wait()
@@ -1017,12 +1249,13 @@ func (bc *brokerConsumer) handleResponses() {
 			Logger.Printf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, result)
 			close(child.trigger)
 			delete(bc.subscriptions, child)
-		} else if errors.Is(result, ErrUnknownTopicOrPartition) ||
-			errors.Is(result, ErrNotLeaderForPartition) ||
-			errors.Is(result, ErrLeaderNotAvailable) ||
-			errors.Is(result, ErrReplicaNotAvailable) ||
-			errors.Is(result, ErrFencedLeaderEpoch) ||
-			errors.Is(result, ErrUnknownLeaderEpoch) {
+		} else if errors.As(result, new(AuthorizationError)) {
+			// retrying is pointless until the ACLs are changed, shut it down
+			child.sendError(result)
+			Logger.Printf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, result)
+			close(child.trigger)
+			delete(bc.subscriptions, child)
+		} else if retriable(result) || errors.Is(result, errEmptyFetchesBehind) {
 			// not an error, but does need redispatching
 			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
 				bc.broker.ID(), child.topic, child.partition, result)
@@ -1061,6 +1294,38 @@ func (bc *brokerConsumer) abort(err error) {
 	}
 }
 
+// reserveFetchBudget reserves room for a partition of the next fetch in the
+// consumer's fetch budget, returning the fetch size to request for it. The
+// first partition of a fetch waits for the budget to become available, the
+// following ones are only added if they fit in what is left.
+func (bc *brokerConsumer) reserveFetchBudget(child *partitionConsumer, first bool) (int32, bool) {
+	budget := bc.consumer.fetchBudget
+	if budget == nil {
+		return child.fetchSize, true
+	}
+	if first {
+		reserved, ok := budget.acquire(int(child.fetchSize))
+		if !ok {
+			return 0, false
+		}
+		child.reservedBytes = reserved
+		return int32(reserved), true
+	}
+	if !budget.tryAcquire(int(child.fetchSize)) {
+		return 0, false
+	}
+	child.reservedBytes = int(child.fetchSize)
+	return child.fetchSize, true
+}
+
+// releaseFetchBudget releases the shares of the fetch budget reserved for a
+// fetch whose response is not fed to the partition consumers.
+func (bc *brokerConsumer) releaseFetchBudget() {
+	for child := range bc.subscriptions {
+		child.releaseFetchBudget(child.reservedBytes)
+	}
+}
+
 // fetchResponse can be nil if no fetch is made, it can occur when
 // all partitions are paused
 func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
@@ -1099,9 +1364,9 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 	// Version 7 adds incremental fetch request support.
 	if bc.consumer.conf.Version.IsAtLeast(V1_1_0_0) {
 		request.Version = 7
-		// We do not currently implement KIP-227 FetchSessions. Setting the id to 0
-		// and the epoch to -1 tells the broker not to generate as session ID we're going
-		// to just ignore anyway.
+		// Unless KIP-227 fetch sessions are enabled, setting the id to 0 and
+		// the epoch to -1 tells the broker not to generate a session ID we're
+		// going to just ignore anyway.
 		request.SessionID = 0
 		request.SessionEpoch = -1
 	}
@@ -1121,16 +1386,57 @@ func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
 		request.RackID = bc.consumer.conf.RackID
 	}
 
+	partitions := make(map[string]map[int32]fetchSessionPartition)
+	var reserved int32
 	for child := range bc.subscriptions {
-		if !child.IsPaused() {
-			request.AddBlock(child.topic, child.partition, child.offset, child.fetchSize, child.leaderEpoch)
+		if child.IsPaused() {
+			continue
+		}
+		fetchSize, ok := bc.reserveFetchBudget(child, reserved == 0)
+		if !ok {
+			// the budget is used up, this partition will be fetched once it is released
+			continue
+		}
+		reserved += fetchSize
+		if partitions[child.topic] == nil {
+			partitions[child.topic] = make(map[int32]fetchSessionPartition)
+		}
+		partitions[child.topic][child.partition] = fetchSessionPartition{
+			fetchOffset: child.offset,
+			maxBytes:    fetchSize,
+			leaderEpoch: child.leaderEpoch,
 		}
 	}
 
-	// avoid to fetch when there is no block
-	if len(request.blocks) == 0 {
+	// avoid to fetch when there is no partition
+	if len(partitions) == 0 {
+		bc.releaseFetchBudget()
 		return nil, nil
 	}
+	if bc.session != nil {
+		// partitions left out of the request are still fetched by the session
+		bc.session.build(request, partitions)
+	} else {
+		for topic, blocks := range partitions {
+			for partition, block := range blocks {
+				request.AddBlock(topic, partition, block.fetchOffset, block.maxBytes, block.leaderEpoch)
+			}
+		}
+	}
+	if bc.consumer.fetchBudget != nil && request.Version >= 3 && reserved < request.MaxBytes {
+		request.MaxBytes = reserved
+	}
 
-	return bc.broker.Fetch(request)
+	response, err := bc.broker.Fetch(request)
+	if bc.session != nil {
+		if err != nil {
+			bc.session.reset()
+		} else {
+			if response.ErrorCode != int16(ErrNoError) {
+				Logger.Printf("consumer/broker/%d starting a new fetch session because %s\n", bc.broker.ID(), KError(response.ErrorCode))
+			}
+			bc.session.update(response)
+		}
+	}
+	return response, err
 }
@@ -43,6 +43,10 @@ type ConsumerGroup interface {
 	// This method should be called inside an infinite loop, when a
 	// server-side rebalance happens, the consumer session will need to be
 	// recreated to get the new claims.
+	// Every rebalance ends the session and releases all of its claims:
+	// incremental (KIP-429) rebalancing, which would keep the claims that
+	// don't move running, is not supported, so no strategy is offered under
+	// the cooperative-sticky protocol name.
 	Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error
 
 	// Errors returns a read channel of errors that occurred during the consumer life-cycle.
@@ -255,7 +259,7 @@ func (c *consumerGroup) retryNewSession(ctx context.Context, topics []string, ha
 	if refreshCoordinator {
 		err := c.client.RefreshCoordinator(c.groupID)
 		if err != nil {
-			if retries <= 0 {
+			if retries <= 0 || errors.As(err, new(AuthorizationError)) {
 				return nil, err
 			}
 			return c.retryNewSession(ctx, topics, handler, retries-1, true)
@@ -271,7 +275,8 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 	}
 	coordinator, err := c.client.Coordinator(c.groupID)
 	if err != nil {
-		if retries <= 0 {
+		// there is no point retrying until the group ACLs are changed
+		if retries <= 0 || errors.As(err, new(AuthorizationError)) {
 			return nil, err
 		}
 
@@ -330,13 +335,13 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 		// group
 		c.memberID = join.MemberId
 		return c.retryNewSession(ctx, topics, handler, retries+1 /*keep retry time*/, false)
-	case ErrFencedInstancedId:
+	case ErrFencedInstanceId:
 		if c.groupInstanceId != nil {
 			Logger.Printf("JoinGroup failed: group instance id %s has been fenced\n", *c.groupInstanceId)
 		}
 		return nil, join.Err
 	default:
-		return nil, join.Err
+		return nil, authorizationError(join.Err, c.groupID)
 	}
 
 	var strategy BalanceStrategy
@@ -397,13 +402,13 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 			return nil, syncGroupResponse.Err
 		}
 		return c.retryNewSession(ctx, topics, handler, retries, true)
-	case ErrFencedInstancedId:
+	case ErrFencedInstanceId:
 		if c.groupInstanceId != nil {
-			Logger.Printf("JoinGroup failed: group instance id %s has been fenced\n", *c.groupInstanceId)
+			Logger.Printf("SyncGroup failed: group instance id %s has been fenced\n", *c.groupInstanceId)
 		}
 		return nil, syncGroupResponse.Err
 	default:
-		return nil, syncGroupResponse.Err
+		return nil, authorizationError(syncGroupResponse.Err, c.groupID)
 	}
 
 	// Retrieve and sort claims
@@ -1041,13 +1046,17 @@ func (s *consumerGroupSession) heartbeatLoop() {
 		case ErrNoError:
 			retries = s.parent.config.Metadata.Retry.Max
 		case ErrRebalanceInProgress:
+			// end the session so that Consume returns and the member rejoins
+			Logger.Printf(
+				"consumergroup/session/%s/%d heartbeat signalled a rebalance, ending session\n",
+				s.MemberID(), s.GenerationID())
 			retries = s.parent.config.Metadata.Retry.Max
 			s.cancel()
 		case ErrUnknownMemberId, ErrIllegalGeneration:
 			return
-		case ErrFencedInstancedId:
+		case ErrFencedInstanceId:
 			if s.parent.groupInstanceId != nil {
-				Logger.Printf("JoinGroup failed: group instance id %s has been fenced\n", *s.parent.groupInstanceId)
+				Logger.Printf("Heartbeat failed: group instance id %s has been fenced\n", *s.parent.groupInstanceId)
 			}
 			s.parent.handleError(resp.Err, "", -1)
 			return
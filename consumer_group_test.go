@@ -233,6 +233,240 @@ func TestConsumerGroupSessionDoesNotRetryForever(t *testing.T) {
 	wg.Wait()
 }
 
+// TestConsumerGroupStaticMembership ensures that a configured group instance
+// id is sent in the JoinGroup request, and that a static member does not leave
+// the group when it is closed, so a restart reclaims the same assignment.
+func TestConsumerGroupStaticMembership(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientID = t.Name()
+	config.Version = V2_3_0_0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Group.InstanceId = "instance-1"
+	config.Consumer.Offsets.AutoCommit.Enable = false
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my-topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my-topic", 0, OffsetOldest, 0).
+			SetOffset("my-topic", 0, OffsetNewest, 1),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my-group", broker0),
+		"HeartbeatRequest": NewMockHeartbeatResponse(t),
+		"JoinGroupRequest": NewMockJoinGroupResponse(t).
+			SetGroupProtocol(RangeBalanceStrategyName).
+			SetMemberId("member-1"),
+		"SyncGroupRequest": NewMockSyncGroupResponse(t).SetMemberAssignment(
+			&ConsumerGroupMemberAssignment{
+				Version: 0,
+				Topics: map[string][]int32{
+					"my-topic": {0},
+				},
+			}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).SetOffset(
+			"my-group", "my-topic", 0, 0, "", ErrNoError,
+		).SetError(ErrNoError),
+		"FetchRequest": NewMockSequence(
+			NewMockFetchResponse(t, 1).
+				SetMessage("my-topic", 0, 0, StringEncoder("foo")),
+			NewMockFetchResponse(t, 1),
+		),
+		"LeaveGroupRequest": NewMockLeaveGroupResponse(t),
+	})
+
+	group, err := NewConsumerGroup([]string{broker0.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &handler{t, cancel}
+	if err := group.Consume(ctx, []string{"my-topic"}, h); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var joins int
+	for _, rr := range broker0.History() {
+		switch req := rr.Request.(type) {
+		case *JoinGroupRequest:
+			joins++
+			if req.Version != 5 {
+				t.Errorf("expected JoinGroupRequest v5, got v%d", req.Version)
+			}
+			if req.GroupInstanceId == nil || *req.GroupInstanceId != "instance-1" {
+				t.Errorf("expected group instance id %q in JoinGroupRequest, got %v", "instance-1", req.GroupInstanceId)
+			}
+		case *SyncGroupRequest:
+			if req.GroupInstanceId == nil || *req.GroupInstanceId != "instance-1" {
+				t.Errorf("expected group instance id %q in SyncGroupRequest, got %v", "instance-1", req.GroupInstanceId)
+			}
+		case *LeaveGroupRequest:
+			t.Error("static member should not leave the group on close")
+		}
+	}
+	if joins == 0 {
+		t.Error("expected a JoinGroupRequest to be sent")
+	}
+}
+
+// TestConsumerGroupStickyRevocation ensures that a member whose partition was
+// revoked by a sticky rebalance starts its session with the partitions it
+// kept, without rejoining the group, and reports its previous assignment in
+// the user data rather than as owned partitions, since it gave every partition
+// up before joining.
+func TestConsumerGroupStickyRevocation(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientID = t.Name()
+	config.Version = V2_3_0_0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	strategy := NewBalanceStrategySticky()
+	config.Consumer.Group.Rebalance.GroupStrategies = []BalanceStrategy{strategy}
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	previous, err := strategy.AssignmentData("member-1", map[string][]int32{"my-topic": {0, 1}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := strategy.AssignmentData("member-1", map[string][]int32{"my-topic": {0}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my-topic", 0, broker0.BrokerID()).
+			SetLeader("my-topic", 1, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my-topic", 0, OffsetOldest, 0).
+			SetOffset("my-topic", 0, OffsetNewest, 1),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my-group", broker0),
+		"HeartbeatRequest": NewMockHeartbeatResponse(t),
+		"JoinGroupRequest": NewMockJoinGroupResponse(t).
+			SetGroupProtocol(StickyBalanceStrategyName).
+			SetGenerationId(2).
+			SetMemberId("member-1").
+			SetLeaderId("member-0"),
+		"SyncGroupRequest": NewMockSyncGroupResponse(t).SetMemberAssignment(&ConsumerGroupMemberAssignment{
+			Topics:   map[string][]int32{"my-topic": {0}},
+			UserData: kept,
+		}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).SetOffset(
+			"my-group", "my-topic", 0, 0, "", ErrNoError,
+		).SetError(ErrNoError),
+		"FetchRequest": NewMockSequence(
+			NewMockFetchResponse(t, 1).
+				SetMessage("my-topic", 0, 0, StringEncoder("foo")),
+			NewMockFetchResponse(t, 1),
+		),
+		"LeaveGroupRequest": NewMockLeaveGroupResponse(t),
+	})
+
+	group, err := NewConsumerGroup([]string{broker0.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the member was assigned both partitions in the previous generation
+	group.(*consumerGroup).userData = previous
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &claimsHandler{handler: handler{t, cancel}}
+	if err := group.Consume(ctx, []string{"my-topic"}, h); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string][]int32{"my-topic": {0}}, h.claims, "expected a session with the kept partition")
+	assert.Equal(t, kept, group.(*consumerGroup).userData, "expected the new assignment to be kept for the next join")
+
+	var joins int
+	for _, rr := range broker0.History() {
+		req, ok := rr.Request.(*JoinGroupRequest)
+		if !ok {
+			continue
+		}
+		joins++
+		meta := new(ConsumerGroupMemberMetadata)
+		if err := decode(req.OrderedGroupProtocols[0].Metadata, meta, nil); err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, meta.OwnedPartitions, "expected no owned partitions after giving them up")
+		assert.Equal(t, previous, meta.UserData, "expected the previous assignment in the user data")
+	}
+	assert.Equal(t, 1, joins, "expected a single join despite the revoked partition")
+}
+
+// claimsHandler records the claims of the session it is set up for.
+type claimsHandler struct {
+	handler
+	claims map[string][]int32
+}
+
+func (h *claimsHandler) Setup(s ConsumerGroupSession) error {
+	h.claims = s.Claims()
+	return nil
+}
+
+// TestConsumerGroupFencedInstanceId ensures that when another member already
+// claims the same group instance id, the fencing error is returned from
+// Consume rather than retried.
+func TestConsumerGroupFencedInstanceId(t *testing.T) {
+	config := NewTestConfig()
+	config.ClientID = t.Name()
+	config.Version = V2_3_0_0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Group.InstanceId = "instance-1"
+	config.Consumer.Group.Rebalance.Retry.Max = 2
+	config.Consumer.Group.Rebalance.Retry.Backoff = 0
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my-topic", 0, broker0.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my-group", broker0),
+		"JoinGroupRequest": NewMockJoinGroupResponse(t).SetError(ErrFencedInstanceId),
+	})
+
+	group, err := NewConsumerGroup([]string{broker0.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = group.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = group.Consume(ctx, []string{"my-topic"}, &handler{t, cancel})
+	if !errors.Is(err, ErrFencedInstanceId) {
+		t.Fatalf("expected ErrFencedInstanceId, got %v", err)
+	}
+
+	var joins int
+	for _, rr := range broker0.History() {
+		if _, ok := rr.Request.(*JoinGroupRequest); ok {
+			joins++
+		}
+	}
+	if joins != 1 {
+		t.Errorf("expected a fenced JoinGroupRequest not to be retried, sent %d", joins)
+	}
+}
+
 func TestConsumerShouldNotRetrySessionIfContextCancelled(t *testing.T) {
 	c := &consumerGroup{
 		config: NewTestConfig(),
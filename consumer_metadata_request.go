@@ -31,6 +31,10 @@ func (r *ConsumerMetadataRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ConsumerMetadataRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ConsumerMetadataRequest) headerVersion() int16 {
 	return 1
 }
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"reflect"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -82,6 +83,95 @@ func TestConsumerOffsetManual(t *testing.T) {
 	broker0.Close()
 }
 
+// A consumer created with an end offset delivers exactly the requested range,
+// even when the boundary falls in the middle of a fetched batch, then closes
+// its Messages channel.
+func TestConsumerEndOffset(t *testing.T) {
+	// Given
+	broker0 := NewMockBroker(t, 0)
+
+	mockFetchResponse := NewMockFetchResponse(t, 30)
+	for i := int64(0); i < 30; i++ {
+		mockFetchResponse.SetMessage("my_topic", 0, i, testMsg)
+	}
+	mockFetchResponse.SetHighWaterMark("my_topic", 0, 30)
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 30),
+		"FetchRequest": mockFetchResponse,
+	})
+
+	// When
+	master, err := NewConsumer([]string{broker0.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consumer, err := master.(BoundedConsumer).ConsumePartitionUntil("my_topic", 0, 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then
+	var offsets []int64
+	timeout := time.After(5 * time.Second)
+consumeLoop:
+	for {
+		select {
+		case message, ok := <-consumer.Messages():
+			if !ok {
+				break consumeLoop
+			}
+			offsets = append(offsets, message.Offset)
+		case <-timeout:
+			t.Fatalf("Messages channel was not closed after reaching the end offset, got offsets %v", offsets)
+		}
+	}
+
+	if len(offsets) != 10 {
+		t.Fatalf("Expected 10 messages, got %d: %v", len(offsets), offsets)
+	}
+	for i, offset := range offsets {
+		if offset != int64(10+i) {
+			t.Errorf("Expected offset %d at position %d, got %d", 10+i, i, offset)
+		}
+	}
+
+	safeClose(t, consumer)
+	safeClose(t, master)
+	broker0.Close()
+}
+
+func TestConsumerEndOffsetBeforeStart(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 30),
+	})
+
+	master, err := NewConsumer([]string{broker0.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfgErr ConfigurationError
+	if _, err := master.(BoundedConsumer).ConsumePartitionUntil("my_topic", 0, 10, 5); !errors.As(err, &cfgErr) {
+		t.Errorf("Expected ConfigurationError for an end offset before the start, got %v", err)
+	}
+
+	safeClose(t, master)
+	broker0.Close()
+}
+
 // If a message is given a key, it can be correctly collected while consuming.
 func TestConsumerMessageWithKey(t *testing.T) {
 	// Given
@@ -2143,3 +2233,702 @@ func TestConsumerError(t *testing.T) {
 		t.Error("unexpected errors.Is")
 	}
 }
+
+func TestConsumerMaxBufferedBytes(t *testing.T) {
+	const (
+		numPartitions = 8
+		numMessages   = 5
+		fetchSize     = 1024
+		budget        = 3 * fetchSize
+	)
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+	broker1 := NewMockBroker(t, 1)
+	defer broker1.Close()
+
+	mockFetchResponse := NewMockFetchResponse(t, 1)
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(broker0.Addr(), broker0.BrokerID()).
+		SetBroker(broker1.Addr(), broker1.BrokerID())
+	offsets := NewMockOffsetResponse(t)
+	for p := int32(0); p < numPartitions; p++ {
+		for i := int64(0); i < numMessages; i++ {
+			mockFetchResponse.SetMessage("my_topic", p, i, testMsg)
+		}
+		mockFetchResponse.SetHighWaterMark("my_topic", p, numMessages)
+		metadata.SetLeader("my_topic", p, p%2)
+		offsets.SetOffset("my_topic", p, OffsetOldest, 0).
+			SetOffset("my_topic", p, OffsetNewest, numMessages)
+	}
+	for _, b := range []*MockBroker{broker0, broker1} {
+		b.SetHandlerByMap(map[string]MockResponse{
+			"MetadataRequest": metadata,
+			"OffsetRequest":   offsets,
+			"FetchRequest":    mockFetchResponse,
+		})
+	}
+
+	config := NewTestConfig()
+	config.Consumer.Fetch.Default = fetchSize
+	config.Consumer.MaxBufferedBytes = budget
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	var wg sync.WaitGroup
+	for p := int32(0); p < numPartitions; p++ {
+		consumer, err := master.ConsumePartition("my_topic", p, OffsetOldest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(consumer PartitionConsumer) {
+			defer wg.Done()
+			for i := int64(0); i < numMessages; i++ {
+				select {
+				case message := <-consumer.Messages():
+					if message.Offset != i {
+						t.Errorf("expected offset %d, got %d", i, message.Offset)
+					}
+				case err := <-consumer.Errors():
+					t.Error(err)
+				}
+			}
+			consumer.AsyncClose()
+			for range consumer.Messages() {
+			}
+		}(consumer)
+	}
+	wg.Wait()
+
+	for _, b := range []*MockBroker{broker0, broker1} {
+		for _, rr := range b.History() {
+			req, ok := rr.Request.(*FetchRequest)
+			if !ok {
+				continue
+			}
+			requested := 0
+			for _, partitions := range req.blocks {
+				for _, block := range partitions {
+					requested += int(block.maxBytes)
+				}
+			}
+			if requested > budget {
+				t.Errorf("fetch request to broker %d asked for %d bytes, more than the %d byte budget", b.BrokerID(), requested, budget)
+			}
+		}
+	}
+}
+
+func TestConsumerMaxBufferedBytesReleasedPerMessage(t *testing.T) {
+	const fetchSize = 1024
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	mockFetchResponse := NewMockFetchResponse(t, 3)
+	for i := int64(0); i < 3; i++ {
+		mockFetchResponse.SetMessage("my_topic", 0, i, testMsg)
+	}
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 3),
+		"FetchRequest": mockFetchResponse,
+	})
+
+	config := NewTestConfig()
+	config.ChannelBufferSize = 0
+	config.Consumer.Fetch.Default = fetchSize
+	config.Consumer.MaxBufferedBytes = fetchSize
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	pc, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, pc)
+
+	// the first message is released as soon as it is delivered, while the
+	// other two are still buffered
+	<-pc.Messages()
+	fb := master.(*consumer).fetchBudget
+	want := fetchSize - len(testMsg)
+	deadline := time.Now().Add(time.Second)
+	for {
+		fb.lock.Lock()
+		used := fb.used
+		fb.lock.Unlock()
+		if used == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d bytes of the budget to be used after one message, got %d", want, used)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumerAuthorizationErrorShutsDownPartition(t *testing.T) {
+	fetchResponse := new(FetchResponse)
+	fetchResponse.AddError("my_topic", 0, ErrTopicAuthorizationFailed)
+
+	broker0 := NewMockBroker(t, 0)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetNewest, 1234).
+			SetOffset("my_topic", 0, OffsetOldest, 0),
+		"FetchRequest": NewMockWrapper(fetchResponse),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Return.Errors = true
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case consumerErr := <-consumer.Errors():
+		var authErr AuthorizationError
+		if !errors.As(consumerErr, &authErr) || authErr.Resource != "my_topic" {
+			t.Fatalf("expected a topic AuthorizationError, got %v", consumerErr.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the authorization error")
+	}
+
+	// the partition consumer shuts down rather than fetching again
+	select {
+	case _, ok := <-consumer.Messages():
+		if ok {
+			t.Fatal("expected no messages")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the partition consumer to shut down")
+	}
+
+	var fetches int
+	for _, rr := range broker0.History() {
+		if _, ok := rr.Request.(*FetchRequest); ok {
+			fetches++
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected the authorization failure not to be retried, sent %d fetch requests", fetches)
+	}
+
+	safeClose(t, master)
+	broker0.Close()
+}
+
+func TestConsumerEmptyFetchesBehindRecheckLeader(t *testing.T) {
+	oldLeader := NewMockBroker(t, 0)
+	defer oldLeader.Close()
+	newLeader := NewMockBroker(t, 1)
+	defer newLeader.Close()
+
+	metadata := func(leader *MockBroker) *MockMetadataResponse {
+		return NewMockMetadataResponse(t).
+			SetBroker(oldLeader.Addr(), oldLeader.BrokerID()).
+			SetBroker(newLeader.Addr(), newLeader.BrokerID()).
+			SetLeader("my_topic", 0, leader.BrokerID())
+	}
+	offsets := NewMockOffsetResponse(t).
+		SetOffset("my_topic", 0, OffsetNewest, 10).
+		SetOffset("my_topic", 0, OffsetOldest, 0)
+
+	// the old leader keeps answering with nothing although it reports a high
+	// water mark ahead of the consumer, as if it silently lost leadership
+	oldLeader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata(oldLeader),
+		"OffsetRequest":   offsets,
+		"FetchRequest":    NewMockFetchResponse(t, 1).SetHighWaterMark("my_topic", 0, 10),
+	})
+	newLeader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata(newLeader),
+		"FetchRequest": NewMockFetchResponse(t, 1).
+			SetMessage("my_topic", 0, 0, testMsg).
+			SetHighWaterMark("my_topic", 0, 10),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Retry.Backoff = 0
+	config.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck = 3
+	master, err := NewConsumer([]string{oldLeader.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	// once the cluster metadata reveals the new leader, the next leader
+	// re-check has to move the consumer over to it
+	fetchedFromOldLeader := func() bool {
+		for _, rr := range oldLeader.History() {
+			if _, ok := rr.Request.(*FetchRequest); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(5 * time.Second); !fetchedFromOldLeader(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer never fetched from the old leader")
+		}
+	}
+	oldLeader.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata(newLeader),
+		"FetchRequest":    NewMockFetchResponse(t, 1).SetHighWaterMark("my_topic", 0, 10),
+	})
+
+	select {
+	case msg := <-consumer.Messages():
+		if msg.Offset != 0 {
+			t.Errorf("expected message at offset 0, got %d", msg.Offset)
+		}
+	case err := <-consumer.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer never moved to the new leader")
+	}
+
+	var fetches int
+	for _, rr := range oldLeader.History() {
+		if _, ok := rr.Request.(*FetchRequest); ok {
+			fetches++
+		}
+	}
+	if fetches < config.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck {
+		t.Errorf("expected at least %d empty fetches from the old leader, got %d", config.Consumer.Fetch.EmptyFetchesBeforeLeaderCheck, fetches)
+	}
+}
+
+// mockResponseFunc adapts a function to the MockResponse interface
+type mockResponseFunc func(reqBody versionedDecoder) encoderWithHeader
+
+func (f mockResponseFunc) For(reqBody versionedDecoder) encoderWithHeader {
+	return f(reqBody)
+}
+
+// TestConsumerFollowsPartitionReassignment moves the leadership of a partition
+// between brokers several times while it is being consumed. Each broker answers
+// with ErrNotLeaderForPartition once the consumer reaches offsets it no longer
+// leads, and the metadata then points at the new leader.
+func TestConsumerFollowsPartitionReassignment(t *testing.T) {
+	const numMessages = 12
+
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+	broker1 := NewMockBroker(t, 1)
+	defer broker1.Close()
+
+	// leadership moves every 4 offsets: broker 0, broker 1, then broker 0 again
+	owner := func(offset int64) int32 { return int32(offset/4) % 2 }
+
+	var lock sync.Mutex
+	leader := int32(0)
+
+	metadata := mockResponseFunc(func(reqBody versionedDecoder) encoderWithHeader {
+		lock.Lock()
+		defer lock.Unlock()
+		return NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetBroker(broker1.Addr(), broker1.BrokerID()).
+			SetLeader("my_topic", 0, leader).
+			For(reqBody)
+	})
+	offsets := NewMockOffsetResponse(t).
+		SetOffset("my_topic", 0, OffsetNewest, numMessages).
+		SetOffset("my_topic", 0, OffsetOldest, 0)
+	fetch := func(brokerID int32) MockResponse {
+		return mockResponseFunc(func(reqBody versionedDecoder) encoderWithHeader {
+			req := reqBody.(*FetchRequest)
+			offset := req.blocks["my_topic"][0].fetchOffset
+			if offset < numMessages && owner(offset) != brokerID {
+				lock.Lock()
+				leader = owner(offset)
+				lock.Unlock()
+				res := &FetchResponse{Version: req.Version}
+				res.AddError("my_topic", 0, ErrNotLeaderForPartition)
+				return res
+			}
+			res := NewMockFetchResponse(t, 1).SetHighWaterMark("my_topic", 0, numMessages)
+			if offset < numMessages {
+				res.SetMessage("my_topic", 0, offset, testMsg)
+			}
+			return res.For(reqBody)
+		})
+	}
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest":   offsets,
+		"FetchRequest":    fetch(broker0.BrokerID()),
+	})
+	broker1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest":   offsets,
+		"FetchRequest":    fetch(broker1.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Retry.Backoff = 10 * time.Millisecond
+	config.Consumer.Return.Errors = true
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	// every message is delivered exactly once and in order across the moves
+	for i := int64(0); i < numMessages; i++ {
+		select {
+		case msg := <-consumer.Messages():
+			assertMessageOffset(t, msg, i)
+		case err := <-consumer.Errors():
+			t.Fatalf("leader moves must be transparent, got %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for offset %d", i)
+		}
+	}
+
+	for _, broker := range []*MockBroker{broker0, broker1} {
+		fetched := false
+		for _, rr := range broker.History() {
+			if _, ok := rr.Request.(*FetchRequest); ok {
+				fetched = true
+			}
+		}
+		if !fetched {
+			t.Errorf("expected broker %d to have been fetched from", broker.BrokerID())
+		}
+	}
+}
+
+// TestConsumerWaitsForLeaderElection ensures that a partition without a
+// leader is waited for until a metadata refresh reports one.
+func TestConsumerWaitsForLeaderElection(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	leaderless := NewMockMetadataResponse(t).
+		SetBroker(broker0.Addr(), broker0.BrokerID()).
+		SetLeader("my_topic", 0, -1)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockSequence(
+			leaderless,
+			leaderless,
+			NewMockMetadataResponse(t).
+				SetBroker(broker0.Addr(), broker0.BrokerID()).
+				SetLeader("my_topic", 0, broker0.BrokerID()),
+		),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 1),
+		"FetchRequest": NewMockFetchResponse(t, 1).
+			SetMessage("my_topic", 0, 0, testMsg),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Retry.Backoff = 10 * time.Millisecond
+	config.Consumer.Retry.LeaderWait = 5 * time.Second
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer)
+
+	select {
+	case message := <-consumer.Messages():
+		assertMessageOffset(t, message, 0)
+	case err := <-consumer.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a message once the leader was elected")
+	}
+}
+
+func TestConsumerLeaderWaitExceeded(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, -1),
+	})
+
+	config := NewTestConfig()
+	config.Consumer.Retry.Backoff = 10 * time.Millisecond
+	config.Consumer.Retry.LeaderWait = 50 * time.Millisecond
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	if _, err := master.ConsumePartition("my_topic", 0, OffsetOldest); !errors.Is(err, ErrLeaderNotAvailable) {
+		t.Fatalf("expected ErrLeaderNotAvailable, got %v", err)
+	}
+}
+
+// mockFetchSessionResponse answers fetch requests like a broker holding a
+// single fetch session of my_topic, only returning the partitions with new
+// messages.
+type mockFetchSessionResponse struct {
+	t        *testing.T
+	lock     sync.Mutex
+	messages map[int32]int64 // number of messages of each partition
+	session  map[int32]int64 // fetch offset of each partition of the session
+	epoch    int32
+	listed   map[int32]int // number of requests listing each partition
+}
+
+func (m *mockFetchSessionResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*FetchRequest)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if req.SessionEpoch == 0 {
+		m.session = make(map[int32]int64)
+	} else if req.SessionID != 42 || req.SessionEpoch != m.epoch {
+		m.t.Errorf("expected session 42 at epoch %d, got id %d epoch %d", m.epoch, req.SessionID, req.SessionEpoch)
+	}
+	m.epoch = req.SessionEpoch + 1
+
+	for partition, block := range req.blocks["my_topic"] {
+		if offset, ok := m.session[partition]; ok && offset == block.fetchOffset {
+			m.t.Errorf("partition %d listed again at unchanged offset %d", partition, offset)
+		}
+		m.session[partition] = block.fetchOffset
+		m.listed[partition]++
+	}
+	for _, partition := range req.forgotten["my_topic"] {
+		delete(m.session, partition)
+	}
+
+	res := &FetchResponse{Version: req.Version, SessionID: 42}
+	for partition, offset := range m.session {
+		if offset < m.messages[partition] {
+			res.AddMessage("my_topic", partition, nil, testMsg, offset)
+			res.GetBlock("my_topic", partition).HighWaterMarkOffset = m.messages[partition]
+		}
+	}
+	return res
+}
+
+func TestConsumerFetchSession(t *testing.T) {
+	broker0 := NewMockBroker(t, 0)
+	defer broker0.Close()
+
+	fetchResponse := &mockFetchSessionResponse{
+		t:        t,
+		messages: map[int32]int64{0: 10},
+		listed:   make(map[int32]int),
+	}
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()).
+			SetLeader("my_topic", 1, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 10).
+			SetOffset("my_topic", 1, OffsetOldest, 0).
+			SetOffset("my_topic", 1, OffsetNewest, 0),
+		"FetchRequest": fetchResponse,
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+	config.ApiVersionsRequest = false
+	config.Consumer.Return.Errors = true
+	config.Consumer.Fetch.Session = true
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer0, err := master.ConsumePartition("my_topic", 0, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer0)
+	consumer1, err := master.ConsumePartition("my_topic", 1, OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, consumer1)
+
+	for i := int64(0); i < 10; i++ {
+		select {
+		case message := <-consumer0.Messages():
+			assertMessageOffset(t, message, i)
+		case err := <-consumer0.Errors():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for offset %d", i)
+		}
+	}
+
+	fetchResponse.lock.Lock()
+	defer fetchResponse.lock.Unlock()
+	// the idle partition is only listed when it joins the session
+	if fetchResponse.listed[1] != 1 {
+		t.Errorf("expected partition 1 to be listed once, got %d", fetchResponse.listed[1])
+	}
+}
+
+// mockLeaderEpochMetadataResponse answers metadata requests like its
+// MockMetadataResponse, with every partition led at the given leader epoch.
+type mockLeaderEpochMetadataResponse struct {
+	*MockMetadataResponse
+	leaderEpoch int32
+}
+
+func (m *mockLeaderEpochMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	res := m.MockMetadataResponse.For(reqBody).(*MetadataResponse)
+	for _, topic := range res.Topics {
+		for _, partition := range topic.Partitions {
+			partition.LeaderEpoch = m.leaderEpoch
+		}
+	}
+	return res
+}
+
+func TestConsumerDetectsLogTruncation(t *testing.T) {
+	leader1 := NewMockBroker(t, 1)
+	defer leader1.Close()
+	leader2 := NewMockBroker(t, 2)
+	defer leader2.Close()
+
+	metadataResponse1 := NewMockMetadataResponse(t).
+		SetBroker(leader1.Addr(), leader1.BrokerID()).
+		SetBroker(leader2.Addr(), leader2.BrokerID()).
+		SetLeader("my_topic", 0, leader1.BrokerID())
+	metadataResponse2 := &mockLeaderEpochMetadataResponse{
+		MockMetadataResponse: NewMockMetadataResponse(t).
+			SetBroker(leader1.Addr(), leader1.BrokerID()).
+			SetBroker(leader2.Addr(), leader2.BrokerID()).
+			SetLeader("my_topic", 0, leader2.BrokerID()),
+		leaderEpoch: 1,
+	}
+
+	fetchResponse := &FetchResponse{Version: 10}
+	fetchResponse.AddRecord("my_topic", 0, nil, testMsg, 1)
+	fetchResponse.AddRecord("my_topic", 0, nil, testMsg, 2)
+	fetchEmptyResponse := &FetchResponse{Version: 10}
+	fetchEmptyResponse.AddError("my_topic", 0, ErrNoError)
+	leader1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse1,
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetNewest, 1234).
+			SetOffset("my_topic", 0, OffsetOldest, 0),
+		"FetchRequest": NewMockSequence(fetchResponse, fetchEmptyResponse),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_1_0_0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Retry.Backoff = 0
+	master, err := NewConsumer([]string{leader1.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, master)
+
+	consumer, err := master.ConsumePartition("my_topic", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.AsyncClose()
+
+	assertMessageOffset(t, <-consumer.Messages(), 1)
+	assertMessageOffset(t, <-consumer.Messages(), 2)
+
+	// the new leader of epoch 1 only has the records of epoch 0 up to offset 2
+	fetchNotLeaderResponse := &FetchResponse{Version: 10}
+	fetchNotLeaderResponse.AddError("my_topic", 0, ErrNotLeaderForPartition)
+	leader1.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse2,
+		"FetchRequest":    NewMockWrapper(fetchNotLeaderResponse),
+	})
+	leader2.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": metadataResponse2,
+		"OffsetForLeaderEpochRequest": NewMockOffsetForLeaderEpochResponse(t).
+			SetEndOffset("my_topic", 0, 0, 2),
+	})
+
+	select {
+	case err := <-consumer.Errors():
+		var truncation LogTruncationError
+		if !errors.As(err, &truncation) {
+			t.Fatalf("expected a LogTruncationError, got %v", err)
+		}
+		if truncation.Offset != 3 || truncation.EndOffset != 2 {
+			t.Errorf("expected truncation at offset 3 to end offset 2, got %+v", truncation)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the truncation error")
+	}
+
+	// the partition consumer shuts down rather than skipping over the truncation
+	select {
+	case _, ok := <-consumer.Messages():
+		if ok {
+			t.Error("expected the messages channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the partition consumer to shut down")
+	}
+
+	var requests int
+	for _, rr := range leader2.History() {
+		if req, ok := rr.Request.(*OffsetForLeaderEpochRequest); ok {
+			requests++
+			block := req.blocks["my_topic"][0]
+			if req.Version != 2 || block == nil || block.currentLeaderEpoch != 1 || block.leaderEpoch != 0 {
+				t.Errorf("unexpected OffsetForLeaderEpochRequest %+v", req)
+			}
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 OffsetForLeaderEpochRequest, got %d", requests)
+	}
+}
@@ -67,21 +67,27 @@ func (c *crc32Field) check(curOffset int, buf []byte) error {
 
 	expected := binary.BigEndian.Uint32(buf[c.startOffset:])
 	if crc != expected {
-		return PacketDecodingError{fmt.Sprintf("CRC didn't match expected %#x got %#x", expected, crc)}
+		return fmt.Errorf("%w, expected %#x got %#x", ErrCRCMismatch, expected, crc)
 	}
 
 	return nil
 }
 
 func (c *crc32Field) crc(curOffset int, buf []byte) (uint32, error) {
-	var tab *crc32.Table
+	tab, err := c.table()
+	if err != nil {
+		return 0, err
+	}
+	return crc32.Checksum(buf[c.startOffset+4:curOffset], tab), nil
+}
+
+func (c *crc32Field) table() (*crc32.Table, error) {
 	switch c.polynomial {
 	case crcIEEE:
-		tab = crc32.IEEETable
+		return crc32.IEEETable, nil
 	case crcCastagnoli:
-		tab = castagnoliTable
+		return castagnoliTable, nil
 	default:
-		return 0, PacketDecodingError{"invalid CRC type"}
+		return nil, PacketDecodingError{"invalid CRC type"}
 	}
-	return crc32.Checksum(buf[c.startOffset+4:curOffset], tab), nil
 }
@@ -68,6 +68,10 @@ func (r *CreatePartitionsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *CreatePartitionsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *CreatePartitionsRequest) headerVersion() int16 {
 	return 1
 }
@@ -64,6 +64,10 @@ func (r *CreatePartitionsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *CreatePartitionsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *CreatePartitionsResponse) headerVersion() int16 {
 	return 0
 }
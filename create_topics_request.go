@@ -83,6 +83,10 @@ func (c *CreateTopicsRequest) version() int16 {
 	return c.Version
 }
 
+func (c *CreateTopicsRequest) setVersion(v int16) {
+	c.Version = v
+}
+
 func (r *CreateTopicsRequest) headerVersion() int16 {
 	return 1
 }
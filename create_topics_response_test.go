@@ -27,6 +27,16 @@ var (
 		0, 42,
 		0, 3, 'm', 's', 'g',
 	}
+
+	createTopicsResponseWithErrorMessage = []byte{
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 38,
+		0, 55, 'R', 'e', 'p', 'l', 'i', 'c', 'a', 't', 'i', 'o', 'n', ' ', 'f', 'a', 'c', 't', 'o', 'r', ':', ' ', '3',
+		' ', 'l', 'a', 'r', 'g', 'e', 'r', ' ', 't', 'h', 'a', 'n', ' ', 'a', 'v', 'a', 'i', 'l', 'a', 'b', 'l', 'e',
+		' ', 'b', 'r', 'o', 'k', 'e', 'r', 's', ':', ' ', '2', '.',
+	}
 )
 
 func TestCreateTopicsResponse(t *testing.T) {
@@ -52,6 +62,23 @@ func TestCreateTopicsResponse(t *testing.T) {
 	testResponse(t, "version 2", resp, createTopicsResponseV2)
 }
 
+func TestCreateTopicsResponseWithErrorMessage(t *testing.T) {
+	resp := new(CreateTopicsResponse)
+	testVersionDecodable(t, "error message", resp, createTopicsResponseWithErrorMessage, 2)
+
+	topicErr, ok := resp.TopicErrors["topic"]
+	if !ok {
+		t.Fatal("expected an error for topic")
+	}
+	if !errors.Is(topicErr, ErrInvalidReplicationFactor) {
+		t.Errorf("expected ErrInvalidReplicationFactor, got %v", topicErr.Err)
+	}
+	want := ErrInvalidReplicationFactor.Error() + " - Replication factor: 3 larger than available brokers: 2."
+	if got := topicErr.Error(); got != want {
+		t.Errorf("TopicError.Error() = %v; want %v", got, want)
+	}
+}
+
 func TestTopicError(t *testing.T) {
 	// Assert that TopicError satisfies error interface
 	var err error = &TopicError{
@@ -33,7 +33,51 @@ var (
 	}
 )
 
-func decompress(cc CompressionCodec, data []byte) ([]byte, error) {
+var (
+	gzipMagic      = []byte{0x1f, 0x8b}
+	lz4FrameMagic  = []byte{0x04, 0x22, 0x4d, 0x18}
+	zstdFrameMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress decompresses data with the codec cc. If that fails and lenient is
+// set, see Consumer.LenientDecompression, the codec guessed from the magic
+// bytes of data is tried as well.
+func decompress(cc CompressionCodec, data []byte, lenient bool) ([]byte, error) {
+	res, err := decompressCodec(cc, data)
+	if err == nil || !lenient || cc == CompressionNone {
+		return res, err
+	}
+
+	sniffed := sniffCompressionCodec(data)
+	if sniffed == cc {
+		return res, err
+	}
+	sniffedRes, sniffedErr := decompressCodec(sniffed, data)
+	if sniffedErr != nil {
+		return res, err
+	}
+	DebugLogger.Printf("decompress: payload declared as %s is actually %s\n", cc, sniffed)
+	return sniffedRes, nil
+}
+
+// sniffCompressionCodec guesses the codec of a compressed payload from its
+// magic bytes. Raw snappy blocks have no magic, so snappy is assumed for any
+// payload not matching one of the other formats.
+func sniffCompressionCodec(data []byte) CompressionCodec {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return CompressionGZIP
+	case bytes.HasPrefix(data, lz4FrameMagic):
+		return CompressionLZ4
+	case bytes.HasPrefix(data, zstdFrameMagic):
+		return CompressionZSTD
+	default:
+		// covers both the xerial framing and raw snappy blocks
+		return CompressionSnappy
+	}
+}
+
+func decompressCodec(cc CompressionCodec, data []byte) ([]byte, error) {
 	switch cc {
 	case CompressionNone:
 		return data, nil
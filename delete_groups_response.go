@@ -66,6 +66,10 @@ func (r *DeleteGroupsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *DeleteGroupsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DeleteGroupsResponse) headerVersion() int16 {
 	return 0
 }
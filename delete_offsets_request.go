@@ -76,6 +76,10 @@ func (r *DeleteOffsetsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DeleteOffsetsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DeleteOffsetsRequest) headerVersion() int16 {
 	return 1
 }
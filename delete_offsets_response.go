@@ -104,6 +104,10 @@ func (r *DeleteOffsetsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *DeleteOffsetsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DeleteOffsetsResponse) headerVersion() int16 {
 	return 0
 }
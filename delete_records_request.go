@@ -78,6 +78,10 @@ func (d *DeleteRecordsRequest) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteRecordsRequest) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteRecordsRequest) headerVersion() int16 {
 	return 1
 }
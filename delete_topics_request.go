@@ -38,6 +38,10 @@ func (d *DeleteTopicsRequest) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteTopicsRequest) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteTopicsRequest) headerVersion() int16 {
 	return 1
 }
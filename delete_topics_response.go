@@ -68,6 +68,10 @@ func (d *DeleteTopicsResponse) version() int16 {
 	return d.Version
 }
 
+func (d *DeleteTopicsResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DeleteTopicsResponse) headerVersion() int16 {
 	return 0
 }
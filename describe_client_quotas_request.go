@@ -133,6 +133,10 @@ func (d *DescribeClientQuotasRequest) version() int16 {
 	return d.Version
 }
 
+func (d *DescribeClientQuotasRequest) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DescribeClientQuotasRequest) headerVersion() int16 {
 	return 1
 }
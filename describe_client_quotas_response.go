@@ -227,6 +227,10 @@ func (d *DescribeClientQuotasResponse) version() int16 {
 	return d.Version
 }
 
+func (d *DescribeClientQuotasResponse) setVersion(v int16) {
+	d.Version = v
+}
+
 func (d *DescribeClientQuotasResponse) headerVersion() int16 {
 	return 0
 }
@@ -0,0 +1,22 @@
+package sarama
+
+import "testing"
+
+var (
+	describeClusterRequest = []byte{
+		0, // IncludeClusterAuthorizedOperations
+		0, // empty tagged fields
+	}
+	describeClusterRequestWithAuthorizedOperations = []byte{
+		1, // IncludeClusterAuthorizedOperations
+		0, // empty tagged fields
+	}
+)
+
+func TestDescribeClusterRequest(t *testing.T) {
+	request := &DescribeClusterRequest{Version: 0}
+	testRequest(t, "basic", request, describeClusterRequest)
+
+	request.IncludeClusterAuthorizedOperations = true
+	testRequest(t, "authorized operations", request, describeClusterRequestWithAuthorizedOperations)
+}
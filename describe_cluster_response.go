@@ -0,0 +1,146 @@
+package sarama
+
+import "time"
+
+// DescribeClusterResponse is the response to a DescribeClusterRequest.
+type DescribeClusterResponse struct {
+	// Version 0 is currently only supported
+	Version int16
+	// ThrottleTime contains the duration for which the request was throttled
+	// due to a quota violation, or zero if the request did not violate any quota.
+	ThrottleTime time.Duration
+	// ErrorCode contains the top-level error code, or 0 if there was no error.
+	ErrorCode KError
+	// ErrorMessage contains the top-level error message, or null if there was
+	// no error.
+	ErrorMessage *string
+	// ClusterID contains the cluster ID that responding broker belongs to.
+	ClusterID string
+	// ControllerID contains the ID of the controller broker.
+	ControllerID int32
+	// Brokers contains each broker in the response.
+	Brokers []*DescribeClusterBroker
+	// ClusterAuthorizedOperations contains a 32-bit bitfield to represent
+	// authorized operations for this cluster.
+	ClusterAuthorizedOperations int32
+}
+
+// DescribeClusterBroker is a broker listed in a DescribeClusterResponse.
+type DescribeClusterBroker struct {
+	BrokerID int32
+	Host     string
+	Port     int32
+	Rack     *string
+}
+
+func (r *DescribeClusterResponse) encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.ErrorCode))
+	if err := pe.putNullableCompactString(r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(r.ClusterID); err != nil {
+		return err
+	}
+	pe.putInt32(r.ControllerID)
+
+	pe.putCompactArrayLength(len(r.Brokers))
+	for _, b := range r.Brokers {
+		pe.putInt32(b.BrokerID)
+		if err := pe.putCompactString(b.Host); err != nil {
+			return err
+		}
+		pe.putInt32(b.Port)
+		if err := pe.putNullableCompactString(b.Rack); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putInt32(r.ClusterAuthorizedOperations)
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *DescribeClusterResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
+	throttleTime, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(throttleTime) * time.Millisecond
+
+	errorCode, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.ErrorCode = KError(errorCode)
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.ClusterID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.ControllerID, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	numBrokers, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Brokers = make([]*DescribeClusterBroker, numBrokers)
+	for i := 0; i < numBrokers; i++ {
+		b := &DescribeClusterBroker{}
+		if b.BrokerID, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if b.Host, err = pd.getCompactString(); err != nil {
+			return err
+		}
+		if b.Port, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if b.Rack, err = pd.getCompactNullableString(); err != nil {
+			return err
+		}
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+		r.Brokers[i] = b
+	}
+
+	if r.ClusterAuthorizedOperations, err = pd.getInt32(); err != nil {
+		return err
+	}
+	_, err = pd.getEmptyTaggedFieldArray()
+	return err
+}
+
+func (r *DescribeClusterResponse) key() int16 {
+	return 60
+}
+
+func (r *DescribeClusterResponse) version() int16 {
+	return r.Version
+}
+
+func (r *DescribeClusterResponse) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *DescribeClusterResponse) headerVersion() int16 {
+	return 1
+}
+
+func (r *DescribeClusterResponse) isValidVersion() bool {
+	return r.Version == 0
+}
+
+func (r *DescribeClusterResponse) requiredVersion() KafkaVersion {
+	return V2_8_0_0
+}
+
+func (r *DescribeClusterResponse) throttleTime() time.Duration {
+	return r.ThrottleTime
+}
@@ -0,0 +1,60 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var describeClusterResponse = []byte{
+	0, 0, 0, 100, // throttle time (100 ms)
+	0, 0, // no error code
+	0,                          // no error message
+	6, 'a', 'b', 'c', 'd', 'e', // cluster id
+	0, 0, 0, 2, // controller id
+	3,          // brokers array length
+	0, 0, 0, 1, // broker id
+	5, 'h', 'o', 's', 't', // host
+	0, 0, 0x23, 0x84, // port 9092
+	3, 'r', '1', // rack
+	0,          // tagged fields
+	0, 0, 0, 2, // broker id
+	5, 'h', 'o', 's', 't', // host
+	0, 0, 0x23, 0x85, // port 9093
+	0,                      // no rack
+	0,                      // tagged fields
+	0x80, 0x00, 0x00, 0x00, // cluster authorized operations (not requested)
+	0, // tagged fields
+}
+
+func TestDescribeClusterResponse(t *testing.T) {
+	response := new(DescribeClusterResponse)
+	testVersionDecodable(t, "brokers and controller", response, describeClusterResponse, 0)
+
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Errorf("unexpected throttle time %v", response.ThrottleTime)
+	}
+	if response.ErrorCode != ErrNoError || response.ErrorMessage != nil {
+		t.Errorf("unexpected error %v %v", response.ErrorCode, response.ErrorMessage)
+	}
+	if response.ClusterID != "abcde" {
+		t.Errorf("unexpected cluster id %q", response.ClusterID)
+	}
+	if response.ControllerID != 2 {
+		t.Errorf("unexpected controller id %d", response.ControllerID)
+	}
+	if len(response.Brokers) != 2 {
+		t.Fatalf("expected 2 brokers, got %d", len(response.Brokers))
+	}
+	first, second := response.Brokers[0], response.Brokers[1]
+	if first.BrokerID != 1 || first.Host != "host" || first.Port != 9092 || first.Rack == nil || *first.Rack != "r1" {
+		t.Errorf("unexpected first broker %+v", first)
+	}
+	if second.BrokerID != 2 || second.Port != 9093 || second.Rack != nil {
+		t.Errorf("unexpected second broker %+v", second)
+	}
+	if response.ClusterAuthorizedOperations != -2147483648 {
+		t.Errorf("unexpected cluster authorized operations %d", response.ClusterAuthorizedOperations)
+	}
+
+	testResponse(t, "brokers and controller", response, describeClusterResponse)
+}
@@ -99,6 +99,10 @@ func (r *DescribeConfigsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeConfigsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeConfigsRequest) headerVersion() int16 {
 	return 1
 }
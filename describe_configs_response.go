@@ -112,6 +112,10 @@ func (r *DescribeConfigsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeConfigsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeConfigsResponse) headerVersion() int16 {
 	return 0
 }
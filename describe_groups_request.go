@@ -38,6 +38,10 @@ func (r *DescribeGroupsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeGroupsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeGroupsRequest) headerVersion() int16 {
 	return 1
 }
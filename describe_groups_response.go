@@ -61,6 +61,10 @@ func (r *DescribeGroupsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeGroupsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeGroupsResponse) headerVersion() int16 {
 	return 0
 }
@@ -78,6 +78,10 @@ func (r *DescribeLogDirsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeLogDirsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeLogDirsRequest) headerVersion() int16 {
 	return 1
 }
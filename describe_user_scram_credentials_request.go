@@ -61,6 +61,10 @@ func (r *DescribeUserScramCredentialsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeUserScramCredentialsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeUserScramCredentialsRequest) headerVersion() int16 {
 	return 2
 }
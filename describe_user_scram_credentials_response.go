@@ -159,6 +159,10 @@ func (r *DescribeUserScramCredentialsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *DescribeUserScramCredentialsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *DescribeUserScramCredentialsResponse) headerVersion() int16 {
 	return 2
 }
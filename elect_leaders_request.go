@@ -0,0 +1,161 @@
+package sarama
+
+// ElectionType selects how a new leader is elected by an ElectLeadersRequest.
+type ElectionType int8
+
+const (
+	// PreferredElection elects the preferred replica, the first replica of
+	// the assignment, if it is in sync.
+	PreferredElection ElectionType = 0
+	// UncleanElection elects any live replica when no in-sync replica is
+	// available, which may lose committed records.
+	UncleanElection ElectionType = 1
+)
+
+type ElectLeadersRequest struct {
+	Version         int16
+	Type            ElectionType       // version 1 or later
+	TopicPartitions map[string][]int32 // nil elects leaders for all partitions
+	TimeoutMs       int32
+}
+
+func (r *ElectLeadersRequest) encode(pe packetEncoder) error {
+	if r.Version >= 1 {
+		pe.putInt8(int8(r.Type))
+	}
+
+	if r.TopicPartitions == nil {
+		if r.Version >= 2 {
+			pe.putCompactArrayLength(-1)
+		} else if err := pe.putArrayLength(-1); err != nil {
+			return err
+		}
+	} else {
+		if r.Version >= 2 {
+			pe.putCompactArrayLength(len(r.TopicPartitions))
+		} else if err := pe.putArrayLength(len(r.TopicPartitions)); err != nil {
+			return err
+		}
+		for topic, partitions := range r.TopicPartitions {
+			if r.Version >= 2 {
+				if err := pe.putCompactString(topic); err != nil {
+					return err
+				}
+				if err := pe.putCompactInt32Array(partitions); err != nil {
+					return err
+				}
+				pe.putEmptyTaggedFieldArray()
+			} else {
+				if err := pe.putString(topic); err != nil {
+					return err
+				}
+				if err := pe.putInt32Array(partitions); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	pe.putInt32(r.TimeoutMs)
+
+	if r.Version >= 2 {
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	if r.Version >= 1 {
+		t, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		r.Type = ElectionType(t)
+	}
+
+	var n int
+	if r.Version >= 2 {
+		// read the length directly, getCompactArrayLength does not tell a
+		// null array from an empty one
+		var length uint64
+		length, err = pd.getUVarint()
+		n = int(length) - 1
+	} else {
+		n, err = pd.getArrayLength()
+	}
+	if err != nil {
+		return err
+	}
+	if n >= 0 {
+		r.TopicPartitions = make(map[string][]int32)
+	}
+	for i := 0; i < n; i++ {
+		var topic string
+		var partitions []int32
+		if r.Version >= 2 {
+			if topic, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if partitions, err = pd.getCompactInt32Array(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		} else {
+			if topic, err = pd.getString(); err != nil {
+				return err
+			}
+			if partitions, err = pd.getInt32Array(); err != nil {
+				return err
+			}
+		}
+		r.TopicPartitions[topic] = partitions
+	}
+
+	if r.TimeoutMs, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	if r.Version >= 2 {
+		if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ElectLeadersRequest) key() int16 {
+	return 43
+}
+
+func (r *ElectLeadersRequest) version() int16 {
+	return r.Version
+}
+
+func (r *ElectLeadersRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *ElectLeadersRequest) headerVersion() int16 {
+	if r.Version >= 2 {
+		return 2
+	}
+	return 1
+}
+
+func (r *ElectLeadersRequest) isValidVersion() bool {
+	return r.Version >= 0 && r.Version <= 2
+}
+
+func (r *ElectLeadersRequest) requiredVersion() KafkaVersion {
+	switch r.Version {
+	case 2, 1:
+		return V2_4_0_0
+	default:
+		return V2_2_0_0
+	}
+}
@@ -0,0 +1,62 @@
+package sarama
+
+import "testing"
+
+var (
+	electLeadersRequestAllPartitionsV0 = []byte{
+		255, 255, 255, 255, // null topic partitions
+		0, 0, 39, 16, // timeout 10000
+	}
+
+	electLeadersRequestOneTopicV1 = []byte{
+		1,          // unclean election
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 2, // 2 partitions
+		0, 0, 0, 0, // partition 0
+		0, 0, 0, 1, // partition 1
+		0, 0, 39, 16, // timeout 10000
+	}
+
+	electLeadersRequestAllPartitionsV2 = []byte{
+		0,            // preferred election
+		0,            // null topic partitions
+		0, 0, 39, 16, // timeout 10000
+		0, // empty tagged fields
+	}
+
+	electLeadersRequestOneTopicV2 = []byte{
+		0,                         // preferred election
+		2,                         // 2-1=1 topic
+		6, 116, 111, 112, 105, 99, // topic name "topic" as compact string
+		2,          // 2-1=1 partition
+		0, 0, 0, 3, // partition 3
+		0,            // empty tagged fields
+		0, 0, 39, 16, // timeout 10000
+		0, // empty tagged fields
+	}
+)
+
+func TestElectLeadersRequest(t *testing.T) {
+	testRequest(t, "all partitions v0", &ElectLeadersRequest{
+		TimeoutMs: 10000,
+	}, electLeadersRequestAllPartitionsV0)
+
+	testRequest(t, "one topic v1", &ElectLeadersRequest{
+		Version:         1,
+		Type:            UncleanElection,
+		TopicPartitions: map[string][]int32{"topic": {0, 1}},
+		TimeoutMs:       10000,
+	}, electLeadersRequestOneTopicV1)
+
+	testRequest(t, "all partitions v2", &ElectLeadersRequest{
+		Version:   2,
+		TimeoutMs: 10000,
+	}, electLeadersRequestAllPartitionsV2)
+
+	testRequest(t, "one topic v2", &ElectLeadersRequest{
+		Version:         2,
+		TopicPartitions: map[string][]int32{"topic": {3}},
+		TimeoutMs:       10000,
+	}, electLeadersRequestOneTopicV2)
+}
@@ -0,0 +1,50 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	electLeadersResponseV0 = []byte{
+		0, 0, 0, 10, // ThrottleTimeMs 10
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 1, // 1 partition
+		0, 0, 0, 0, // partition 0
+		0, 84, // ErrElectionNotNeeded
+		255, 255, // null error message
+	}
+
+	electLeadersResponseV2 = []byte{
+		0, 0, 0, 10, // ThrottleTimeMs 10
+		0, 0, // no error
+		2,                         // 2-1=1 topic
+		6, 116, 111, 112, 105, 99, // topic name "topic" as compact string
+		2,          // 2-1=1 partition
+		0, 0, 0, 1, // partition 1
+		0, 83, // ErrEligibleLeadersNotAvailable
+		5, 110, 111, 110, 101, // error message "none"
+		0, // empty tagged fields
+		0, // empty tagged fields
+		0, // empty tagged fields
+	}
+)
+
+func TestElectLeadersResponse(t *testing.T) {
+	response := &ElectLeadersResponse{ThrottleTimeMs: 10}
+	response.AddResult("topic", 0, ErrElectionNotNeeded, nil)
+	testResponse(t, "v0", response, electLeadersResponseV0)
+
+	message := "none"
+	response = &ElectLeadersResponse{Version: 2, ThrottleTimeMs: 10}
+	response.AddResult("topic", 1, ErrEligibleLeadersNotAvailable, &message)
+	testResponse(t, "v2", response, electLeadersResponseV2)
+
+	decoded := new(ElectLeadersResponse)
+	testVersionDecodable(t, "v2", decoded, electLeadersResponseV2, 2)
+	result := decoded.ReplicaElectionResults["topic"][1]
+	if result == nil || !errors.Is(result.ErrorCode, ErrEligibleLeadersNotAvailable) || *result.ErrorMessage != message {
+		t.Errorf("unexpected result for partition 1: %+v", result)
+	}
+}
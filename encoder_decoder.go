@@ -42,6 +42,12 @@ func encode(e encoder, metricRegistry metrics.Registry) ([]byte, error) {
 		return nil, err
 	}
 
+	// the sizing pass must agree with what was actually written, otherwise the
+	// request would be sent with trailing garbage or missing bytes
+	if realEnc.off != prepEnc.length {
+		return nil, PacketEncodingError{fmt.Sprintf("encoded %d bytes but sizing pass computed %d", realEnc.off, prepEnc.length)}
+	}
+
 	return realEnc.raw, nil
 }
 
@@ -79,6 +85,12 @@ func decode(buf []byte, in decoder, metricRegistry metrics.Registry) error {
 }
 
 func versionedDecode(buf []byte, in versionedDecoder, version int16, metricRegistry metrics.Registry) error {
+	return versionedDecodeLenient(buf, in, version, metricRegistry, false)
+}
+
+// versionedDecodeLenient is versionedDecode with the records contained in buf
+// decoded with Consumer.LenientDecompression if lenient is set.
+func versionedDecodeLenient(buf []byte, in versionedDecoder, version int16, metricRegistry metrics.Registry, lenient bool) error {
 	if buf == nil {
 		return nil
 	}
@@ -86,6 +98,7 @@ func versionedDecode(buf []byte, in versionedDecoder, version int16, metricRegis
 	helper := realDecoder{
 		raw:      buf,
 		registry: metricRegistry,
+		lenient:  lenient,
 	}
 	err := in.decode(&helper, version)
 	if err != nil {
@@ -0,0 +1,26 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+// shortEncoder writes fewer bytes on the real pass than it reports on the
+// sizing pass
+type shortEncoder struct{}
+
+func (shortEncoder) encode(pe packetEncoder) error {
+	pe.putInt32(1)
+	if _, ok := pe.(*prepEncoder); ok {
+		pe.putInt32(2)
+	}
+	return nil
+}
+
+func TestEncodeDetectsSizeMismatch(t *testing.T) {
+	_, err := encode(shortEncoder{}, nil)
+	var encodingErr PacketEncodingError
+	if !errors.As(err, &encodingErr) {
+		t.Fatalf("expected PacketEncodingError, got %v", err)
+	}
+}
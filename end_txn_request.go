@@ -46,6 +46,10 @@ func (a *EndTxnRequest) version() int16 {
 	return a.Version
 }
 
+func (a *EndTxnRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (r *EndTxnRequest) headerVersion() int16 {
 	return 1
 }
@@ -40,6 +40,10 @@ func (e *EndTxnResponse) version() int16 {
 	return e.Version
 }
 
+func (e *EndTxnResponse) setVersion(v int16) {
+	e.Version = v
+}
+
 func (r *EndTxnResponse) headerVersion() int16 {
 	return 0
 }
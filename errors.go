@@ -29,6 +29,11 @@ var ErrInvalidPartition = errors.New("kafka: partitioner returned an invalid par
 // ErrAlreadyConnected is the error returned when calling Open() on a Broker that is already connected or connecting.
 var ErrAlreadyConnected = errors.New("kafka: broker connection already initiated")
 
+// ErrProtocolDesync is returned for the requests in flight on a broker connection when the responses
+// read from it no longer line up with the requests sent, for instance a response with an unexpected
+// correlation ID. The connection is closed and the broker is connected again by its next Open.
+var ErrProtocolDesync = errors.New("kafka: broker responses out of sync with requests")
+
 // ErrNotConnected is the error returned when trying to send or call Close() on a Broker that is not connected.
 var ErrNotConnected = errors.New("kafka: broker not connected")
 
@@ -47,10 +52,22 @@ var ErrMessageTooLarge = errors.New("kafka: message is larger than Consumer.Fetc
 // a RecordBatch.
 var ErrConsumerOffsetNotAdvanced = errors.New("kafka: consumer offset was not advanced after a RecordBatch")
 
+// ErrRequestRateLimited is returned when a request would exceed Net.RateLimit.RequestsPerSecond
+// and Net.RateLimit.Block is disabled.
+var ErrRequestRateLimited = errors.New("kafka: request rate limit for broker exceeded")
+
+// ErrPingTimeout is reported by Client.Ping for a broker which did not answer
+// before the timeout.
+var ErrPingTimeout = errors.New("kafka: broker did not answer the ping in time")
+
 // ErrControllerNotAvailable is returned when server didn't give correct controller id. May be kafka server's version
 // is lower than 0.10.0.0.
 var ErrControllerNotAvailable = errors.New("kafka: controller is not available")
 
+// ErrNoBrokersInMetadata is returned when a broker keeps answering metadata requests with an empty
+// list of brokers, which can happen transiently (e.g. while the broker is starting up).
+var ErrNoBrokersInMetadata = errors.New("kafka: metadata response did not contain any brokers")
+
 // ErrNoTopicsToUpdateMetadata is returned when Meta.Full is set to false but no specific topics were found to update
 // the metadata.
 var ErrNoTopicsToUpdateMetadata = errors.New("kafka: no specific topics to update metadata")
@@ -159,6 +176,58 @@ func (err PacketDecodingError) Error() string {
 	return fmt.Sprintf("kafka: error decoding packet: %s", err.Info)
 }
 
+// ResponseDecodingError is returned by a Broker when a response was received
+// but could not be decoded, as opposed to the connection errors returned when
+// no response was received at all. The response being decoded may have been
+// partially filled in before decoding failed.
+type ResponseDecodingError struct {
+	Err error
+}
+
+func (err ResponseDecodingError) Error() string {
+	return fmt.Sprintf("kafka: response received but could not be decoded: %v", err.Err)
+}
+
+func (err ResponseDecodingError) Unwrap() error {
+	return err.Err
+}
+
+// ErrCRCMismatch is returned when decoding a message or record batch whose CRC does not match its
+// contents.
+var ErrCRCMismatch = errors.New("kafka: CRC didn't match")
+
+// CorruptRecordsError is returned by a PartitionConsumer when the records fetched from Offset onwards
+// fail their CRC check. The records before them are still delivered, consuming them again retries the
+// fetch, which fails the same way if the records are corrupt in the log rather than in transit.
+type CorruptRecordsError struct {
+	Offset int64
+	Err    error
+}
+
+func (err CorruptRecordsError) Error() string {
+	return fmt.Sprintf("kafka: records from offset %d are corrupt: %v", err.Offset, err.Err)
+}
+
+func (err CorruptRecordsError) Unwrap() error {
+	return err.Err
+}
+
+// LogTruncationError is returned by a PartitionConsumer which found that the log of its partition was
+// truncated below the offset it had consumed up to, by an unclean leader election for example. The
+// records consumed from EndOffset onwards are not in the log of the new leader. The PartitionConsumer
+// shuts down, consuming can be resumed from EndOffset.
+type LogTruncationError struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	EndOffset int64
+}
+
+func (err LogTruncationError) Error() string {
+	return fmt.Sprintf("kafka: log of %s/%d was truncated to offset %d, below the consumed offset %d",
+		err.Topic, err.Partition, err.EndOffset, err.Offset)
+}
+
 // ConfigurationError is the type of error returned from a constructor (e.g. NewClient, or NewConsumer)
 // when the specified configuration is invalid.
 type ConfigurationError string
@@ -267,6 +336,9 @@ const (
 	ErrProducerFenced                     KError = 90 // Errors.PRODUCER_FENCED
 )
 
+// ErrFencedInstanceId is the correctly spelled name of ErrFencedInstancedId.
+const ErrFencedInstanceId = ErrFencedInstancedId
+
 func (err KError) Error() string {
 	// Error messages stolen/adapted from
 	// https://kafka.apache.org/protocol#protocol_error_codes
@@ -451,7 +523,109 @@ func (err KError) Error() string {
 		return "kafka server: This record has failed the validation on broker and hence will be rejected"
 	case ErrUnstableOffsetCommit:
 		return "kafka server: There are unstable offsets that need to be cleared"
+	case ErrThrottlingQuotaExceeded:
+		return "kafka server: The throttling quota has been exceeded"
+	case ErrProducerFenced:
+		return "kafka server: There is a newer producer with the same transactionalId which fences the current one"
 	}
 
 	return fmt.Sprintf("Unknown error, how did this happen? Error code = %d", err)
 }
+
+// Retriable returns true if the error is transient, meaning the same request
+// may succeed if it is sent again, possibly after refreshing metadata. This
+// follows the classification of the Java client, where these errors are
+// subclasses of RetriableException.
+func (err KError) Retriable() bool {
+	switch err {
+	case ErrInvalidMessage,
+		ErrUnknownTopicOrPartition,
+		ErrLeaderNotAvailable,
+		ErrNotLeaderForPartition,
+		ErrRequestTimedOut,
+		ErrReplicaNotAvailable,
+		ErrNetworkException,
+		ErrOffsetsLoadInProgress,
+		ErrConsumerCoordinatorNotAvailable,
+		ErrNotCoordinatorForConsumer,
+		ErrNotEnoughReplicas,
+		ErrNotEnoughReplicasAfterAppend,
+		ErrNotController,
+		ErrKafkaStorageError,
+		ErrFetchSessionIDNotFound,
+		ErrInvalidFetchSessionEpoch,
+		ErrListenerNotFound,
+		ErrFencedLeaderEpoch,
+		ErrUnknownLeaderEpoch,
+		ErrOffsetNotAvailable,
+		ErrPreferredLeaderNotAvailable,
+		ErrEligibleLeadersNotAvailable,
+		ErrElectionNotNeeded,
+		ErrUnstableOffsetCommit,
+		ErrThrottlingQuotaExceeded:
+		return true
+	}
+	return false
+}
+
+// retriable returns true if err is, or wraps, a Retriable KError.
+func retriable(err error) bool {
+	var kerr KError
+	return errors.As(err, &kerr) && kerr.Retriable()
+}
+
+// IsAuthorizationError returns true if the error means the client is not
+// authorized by the broker's ACLs to perform the operation. Such errors are
+// never retriable, the request will keep failing until the ACLs are changed.
+func (err KError) IsAuthorizationError() bool {
+	return err.authorizationResourceType() != ""
+}
+
+func (err KError) authorizationResourceType() string {
+	switch err {
+	case ErrTopicAuthorizationFailed:
+		return "topic"
+	case ErrGroupAuthorizationFailed:
+		return "group"
+	case ErrClusterAuthorizationFailed:
+		return "cluster"
+	case ErrTransactionalIDAuthorizationFailed:
+		return "transactional id"
+	case ErrDelegationTokenAuthorizationFailed:
+		return "delegation token"
+	}
+	return ""
+}
+
+// AuthorizationError is returned when the broker rejects an operation because the client is not
+// authorized to access the resource it targets. It wraps the KError returned by the broker, so
+// errors.Is(err, ErrTopicAuthorizationFailed) keeps matching.
+type AuthorizationError struct {
+	Err          KError
+	ResourceType string // "topic", "group", "cluster", "transactional id" or "delegation token"
+	Resource     string // name of the topic, group etc., empty if unknown or for the cluster
+}
+
+func (err AuthorizationError) Error() string {
+	if err.Resource == "" {
+		return err.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s %s)", err.Err.Error(), err.ResourceType, err.Resource)
+}
+
+func (err AuthorizationError) Unwrap() error {
+	return err.Err
+}
+
+// authorizationError wraps kerr in an AuthorizationError for the named resource if it is an
+// authorization failure, otherwise kerr is returned unchanged.
+func authorizationError(kerr KError, resource string) error {
+	if !kerr.IsAuthorizationError() {
+		return kerr
+	}
+	return AuthorizationError{
+		Err:          kerr,
+		ResourceType: kerr.authorizationResourceType(),
+		Resource:     resource,
+	}
+}
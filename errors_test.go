@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -63,3 +64,85 @@ func TestSentinelWithMultipleWrappedErrors(t *testing.T) {
 		t.Errorf("unwrapped value unexpected result")
 	}
 }
+
+func TestKErrorRetriable(t *testing.T) {
+	tests := []struct {
+		err       KError
+		retriable bool
+		message   string
+	}{
+		{ErrKafkaStorageError, true, "Disk error when trying to access log file on the disk"},
+		{ErrFencedLeaderEpoch, true, "leader epoch in the request is older than the epoch on the broker"},
+		{ErrUnknownLeaderEpoch, true, "leader epoch in the request is newer than the epoch on the broker"},
+		{ErrListenerNotFound, true, "no listener on the leader broker"},
+		{ErrFencedInstanceId, false, "same group.instance.id"},
+		{ErrNotLeaderForPartition, true, "not the leader"},
+		{ErrThrottlingQuotaExceeded, true, "throttling quota"},
+		{ErrOffsetOutOfRange, false, "outside the range of offsets"},
+		{ErrProducerFenced, false, "fences the current one"},
+		{ErrNoError, false, "Not an error"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Retriable(); got != tt.retriable {
+			t.Errorf("%d: expected Retriable() = %v, got %v", int16(tt.err), tt.retriable, got)
+		}
+		if msg := tt.err.Error(); !strings.Contains(msg, tt.message) {
+			t.Errorf("%d: expected message containing %q, got %q", int16(tt.err), tt.message, msg)
+		}
+	}
+}
+
+func TestKErrorMessages(t *testing.T) {
+	for code := ErrUnknown; code <= ErrProducerFenced; code++ {
+		if strings.HasPrefix(code.Error(), "Unknown error") {
+			t.Errorf("error code %d has no message", int16(code))
+		}
+	}
+}
+
+func TestAuthorizationError(t *testing.T) {
+	tests := []struct {
+		err          KError
+		resourceType string
+	}{
+		{ErrTopicAuthorizationFailed, "topic"},
+		{ErrGroupAuthorizationFailed, "group"},
+		{ErrClusterAuthorizationFailed, "cluster"},
+		{ErrTransactionalIDAuthorizationFailed, "transactional id"},
+		{ErrDelegationTokenAuthorizationFailed, "delegation token"},
+	}
+
+	for _, tt := range tests {
+		if !tt.err.IsAuthorizationError() {
+			t.Errorf("%d: expected IsAuthorizationError() to be true", int16(tt.err))
+		}
+		if tt.err.Retriable() {
+			t.Errorf("%d: authorization errors must not be retriable", int16(tt.err))
+		}
+
+		err := authorizationError(tt.err, "my_resource")
+		var authErr AuthorizationError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("%d: expected an AuthorizationError, got %T", int16(tt.err), err)
+		}
+		if authErr.ResourceType != tt.resourceType || authErr.Resource != "my_resource" {
+			t.Errorf("%d: unexpected resource %s %q", int16(tt.err), authErr.ResourceType, authErr.Resource)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%d: expected the AuthorizationError to wrap the KError", int16(tt.err))
+		}
+		if !strings.Contains(err.Error(), "my_resource") {
+			t.Errorf("%d: expected the resource in the message, got %q", int16(tt.err), err.Error())
+		}
+	}
+
+	for _, kerr := range []KError{ErrSASLAuthenticationFailed, ErrNotLeaderForPartition, ErrUnknown} {
+		if kerr.IsAuthorizationError() {
+			t.Errorf("%d: expected IsAuthorizationError() to be false", int16(kerr))
+		}
+		if err := authorizationError(kerr, "my_resource"); err != kerr {
+			t.Errorf("%d: expected the KError to be returned unchanged, got %v", int16(kerr), err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package sarama
+
+import "sync"
+
+// fetchBudget is a byte semaphore shared by all the broker consumers of a
+// consumer, bounding the total size of the fetch responses they buffer.
+type fetchBudget struct {
+	capacity int
+
+	lock     sync.Mutex
+	used     int
+	released chan none // closed and replaced by every release, waking up acquire
+	closed   chan none // closed once the consumer is closed
+}
+
+func newFetchBudget(capacity int) *fetchBudget {
+	return &fetchBudget{
+		capacity: capacity,
+		released: make(chan none),
+		closed:   make(chan none),
+	}
+}
+
+// acquire reserves n bytes, blocking until they are available or the budget
+// is closed, in which case it returns false. Reservations larger than the
+// whole budget are capped to it so that they can eventually succeed. It
+// returns the number of bytes actually reserved.
+func (b *fetchBudget) acquire(n int) (int, bool) {
+	if n > b.capacity {
+		n = b.capacity
+	}
+
+	b.lock.Lock()
+	for b.used+n > b.capacity {
+		released := b.released
+		b.lock.Unlock()
+		select {
+		case <-released:
+		case <-b.closed:
+			return 0, false
+		}
+		b.lock.Lock()
+	}
+	b.used += n
+	b.lock.Unlock()
+	return n, true
+}
+
+// tryAcquire reserves n bytes if they are available right now, without
+// blocking.
+func (b *fetchBudget) tryAcquire(n int) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.used+n > b.capacity {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *fetchBudget) release(n int) {
+	if n == 0 {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.used -= n
+	close(b.released)
+	b.released = make(chan none)
+}
+
+// close fails the acquire calls which wait for the budget, now or later.
+func (b *fetchBudget) close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	select {
+	case <-b.closed:
+	default:
+		close(b.closed)
+	}
+}
@@ -0,0 +1,74 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFetchBudget(t *testing.T) {
+	b := newFetchBudget(100)
+
+	if n, _ := b.acquire(250); n != 100 {
+		t.Fatalf("expected an oversized reservation to be capped to 100, got %d", n)
+	}
+	if b.tryAcquire(1) {
+		t.Fatal("expected the budget to be exhausted")
+	}
+
+	acquired := make(chan int)
+	go func() {
+		n, _ := b.acquire(40)
+		acquired <- n
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected acquire to block while the budget is exhausted")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b.release(100)
+	select {
+	case n := <-acquired:
+		if n != 40 {
+			t.Errorf("expected 40 bytes to be reserved, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected acquire to succeed once the budget is released")
+	}
+
+	if !b.tryAcquire(60) {
+		t.Error("expected the remaining 60 bytes to be available")
+	}
+}
+
+func TestFetchBudgetClose(t *testing.T) {
+	b := newFetchBudget(100)
+	if _, ok := b.acquire(100); !ok {
+		t.Fatal("expected the budget to be available")
+	}
+
+	acquired := make(chan bool)
+	go func() {
+		_, ok := b.acquire(1)
+		acquired <- ok
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected acquire to block while the budget is exhausted")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b.close()
+	select {
+	case ok := <-acquired:
+		if ok {
+			t.Error("expected acquire to fail once the budget is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected close to end the wait for the budget")
+	}
+	if _, ok := b.acquire(1); ok {
+		t.Error("expected acquire to fail on a closed budget")
+	}
+	b.close()
+}
@@ -201,10 +201,9 @@ func (r *FetchRequest) decode(pd packetDecoder, version int16) (err error) {
 	if err != nil {
 		return err
 	}
-	if topicCount == 0 {
-		return nil
+	if topicCount > 0 {
+		r.blocks = make(map[string]map[int32]*fetchRequestBlock)
 	}
-	r.blocks = make(map[string]map[int32]*fetchRequestBlock)
 	for i := 0; i < topicCount; i++ {
 		topic, err := pd.getString()
 		if err != nil {
@@ -276,6 +275,10 @@ func (r *FetchRequest) version() int16 {
 	return r.Version
 }
 
+func (r *FetchRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *FetchRequest) headerVersion() int16 {
 	return 1
 }
@@ -311,6 +314,20 @@ func (r *FetchRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns the version needed by the rack, the fetch session and
+// the isolation level of the request.
+func (r *FetchRequest) fieldsVersion() int16 {
+	switch {
+	case r.RackID != "":
+		return 11
+	case r.SessionID != 0 || r.SessionEpoch > 0 || len(r.forgotten) > 0:
+		return 7
+	case r.Isolation != ReadUncommitted:
+		return 4
+	}
+	return 0
+}
+
 func (r *FetchRequest) AddBlock(topic string, partitionID int32, fetchOffset int64, maxBytes int32, leaderEpoch int32) {
 	if r.blocks == nil {
 		r.blocks = make(map[string]map[int32]*fetchRequestBlock)
@@ -334,3 +351,12 @@ func (r *FetchRequest) AddBlock(topic string, partitionID int32, fetchOffset int
 
 	r.blocks[topic][partitionID] = tmp
 }
+
+// forgetPartition removes the partition from the fetch session of an
+// incremental fetch request.
+func (r *FetchRequest) forgetPartition(topic string, partitionID int32) {
+	if r.forgotten == nil {
+		r.forgotten = make(map[string][]int32)
+	}
+	r.forgotten[topic] = append(r.forgotten[topic], partitionID)
+}
@@ -59,6 +59,9 @@ type FetchResponseBlock struct {
 	PreferredReadReplica int32
 	// RecordsSet contains the record data.
 	RecordsSet []*Records
+	// Corrupt is set when records failed their CRC check, RecordsSet then
+	// contains the records before them.
+	Corrupt *CorruptRecordsError
 
 	Partial bool
 	Records *Records // deprecated: use FetchResponseBlock.RecordsSet
@@ -147,6 +150,19 @@ func (b *FetchResponseBlock) decode(pd packetDecoder, version int16) (err error)
 				}
 				break
 			}
+			// keep the records decoded before the corrupt ones, the
+			// consumer reports the corruption once it reaches it
+			var corrupt CorruptRecordsError
+			if errors.As(err, &corrupt) {
+				b.Corrupt = &corrupt
+				if records.MsgSet != nil && len(records.MsgSet.Messages) > 0 {
+					b.RecordsSet = append(b.RecordsSet, records)
+					if b.Records == nil {
+						b.Records = records
+					}
+				}
+				break
+			}
 			return err
 		}
 
@@ -382,6 +398,10 @@ func (r *FetchResponse) version() int16 {
 	return r.Version
 }
 
+func (r *FetchResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *FetchResponse) headerVersion() int16 {
 	return 0
 }
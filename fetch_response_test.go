@@ -376,6 +376,48 @@ func TestOneRecordFetchResponse(t *testing.T) {
 	}
 }
 
+func TestCorruptRecordFetchResponse(t *testing.T) {
+	corrupt := append([]byte(nil), oneRecordFetchResponse...)
+	corrupt[len(corrupt)-1]++ // last byte of the header value
+
+	response := FetchResponse{}
+	testVersionDecodable(t, "corrupt record", &response, corrupt, 4)
+
+	block := response.GetBlock("topic", 5)
+	if block == nil {
+		t.Fatal("GetBlock didn't return block.")
+	}
+	if block.Corrupt == nil || block.Corrupt.Offset != 0 || !errors.Is(block.Corrupt, ErrCRCMismatch) {
+		t.Fatalf("expected the batch at offset 0 to be reported as corrupt, got %v", block.Corrupt)
+	}
+	if n, err := block.numRecords(); err != nil || n != 0 {
+		t.Errorf("expected no records from a corrupt batch, got %d, %v", n, err)
+	}
+}
+
+func TestCorruptMessageFetchResponseKeepsPreviousMessages(t *testing.T) {
+	response := &FetchResponse{}
+	response.AddMessage("topic", 5, nil, StringEncoder("first"), 10)
+	response.AddMessage("topic", 5, nil, StringEncoder("second"), 11)
+	buf, err := encode(response, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[bytes.Index(buf, []byte("second"))] = 'S'
+
+	decoded := FetchResponse{}
+	testVersionDecodable(t, "corrupt message", &decoded, buf, 0)
+
+	block := decoded.GetBlock("topic", 5)
+	if block.Corrupt == nil || block.Corrupt.Offset != 11 || !errors.Is(block.Corrupt, ErrCRCMismatch) {
+		t.Fatalf("expected the message at offset 11 to be reported as corrupt, got %v", block.Corrupt)
+	}
+	messages := block.RecordsSet[0].MsgSet.Messages
+	if len(messages) != 1 || messages[0].Offset != 10 || string(messages[0].Msg.Value) != "first" {
+		t.Errorf("expected the message before the corrupt one to be kept, got %v", messages)
+	}
+}
+
 func TestPartailFetchResponse(t *testing.T) {
 	response := FetchResponse{}
 	testVersionDecodable(t, "partial record", &response, partialFetchResponse, 4)
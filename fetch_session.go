@@ -0,0 +1,82 @@
+package sarama
+
+import (
+	"errors"
+	"math"
+)
+
+// fetchSessionPartition holds the fetch parameters of a partition.
+type fetchSessionPartition struct {
+	fetchOffset int64
+	maxBytes    int32
+	leaderEpoch int32
+}
+
+// fetchSession tracks a KIP-227 incremental fetch session with a broker. The
+// first request of a session lists every partition and asks the broker to
+// create the session. The following ones only list the partitions whose fetch
+// parameters changed since the previous request and the partitions to remove
+// from the session, the broker keeps fetching the other partitions on its own.
+type fetchSession struct {
+	id    int32
+	epoch int32
+	// partitions holds the fetch parameters the broker holds for the session.
+	partitions map[string]map[int32]fetchSessionPartition
+	// pending holds the fetch parameters of the request in flight, they are
+	// the session's once its response arrives.
+	pending map[string]map[int32]fetchSessionPartition
+}
+
+// build adds the partitions to fetch to the request, leaving out the ones the
+// session already fetches with the same parameters.
+func (s *fetchSession) build(request *FetchRequest, partitions map[string]map[int32]fetchSessionPartition) {
+	request.SessionID = s.id
+	request.SessionEpoch = s.epoch
+	s.pending = partitions
+
+	for topic, blocks := range partitions {
+		for partition, block := range blocks {
+			if previous, ok := s.partitions[topic][partition]; ok && previous == block {
+				continue
+			}
+			request.AddBlock(topic, partition, block.fetchOffset, block.maxBytes, block.leaderEpoch)
+		}
+	}
+	for topic, blocks := range s.partitions {
+		for partition := range blocks {
+			if _, ok := partitions[topic][partition]; !ok {
+				request.forgetPartition(topic, partition)
+			}
+		}
+	}
+}
+
+// update moves the session to its next epoch once the response of the request
+// prepared by build has arrived. A response without a session, or with an
+// error such as ErrFetchSessionIDNotFound, starts a new session on the next
+// request.
+func (s *fetchSession) update(response *FetchResponse) {
+	if !errors.Is(KError(response.ErrorCode), ErrNoError) || response.SessionID == 0 ||
+		(s.epoch != 0 && response.SessionID != s.id) {
+		s.reset()
+		return
+	}
+
+	s.id = response.SessionID
+	s.partitions = s.pending
+	s.pending = nil
+	if s.epoch == math.MaxInt32 {
+		// epoch 0 creates a session, wrap around to 1
+		s.epoch = 1
+	} else {
+		s.epoch++
+	}
+}
+
+// reset makes the next request create a new session listing every partition.
+func (s *fetchSession) reset() {
+	s.id = 0
+	s.epoch = 0
+	s.partitions = nil
+	s.pending = nil
+}
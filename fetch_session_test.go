@@ -0,0 +1,71 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFetchSessionBuildsIncrementalRequests(t *testing.T) {
+	session := new(fetchSession)
+	partitions := func(offsets map[int32]int64) map[string]map[int32]fetchSessionPartition {
+		blocks := make(map[int32]fetchSessionPartition)
+		for partition, offset := range offsets {
+			blocks[partition] = fetchSessionPartition{fetchOffset: offset, maxBytes: 1024}
+		}
+		return map[string]map[int32]fetchSessionPartition{"my_topic": blocks}
+	}
+	build := func(offsets map[int32]int64) *FetchRequest {
+		request := &FetchRequest{Version: 7}
+		session.build(request, partitions(offsets))
+		return request
+	}
+	requested := func(request *FetchRequest) map[int32]int64 {
+		offsets := make(map[int32]int64)
+		for partition, block := range request.blocks["my_topic"] {
+			offsets[partition] = block.fetchOffset
+		}
+		return offsets
+	}
+
+	// the first request creates the session with every partition
+	request := build(map[int32]int64{0: 10, 1: 20})
+	if request.SessionID != 0 || request.SessionEpoch != 0 {
+		t.Errorf("expected a new session, got id %d epoch %d", request.SessionID, request.SessionEpoch)
+	}
+	if offsets := requested(request); !reflect.DeepEqual(offsets, map[int32]int64{0: 10, 1: 20}) {
+		t.Errorf("expected both partitions, got %v", offsets)
+	}
+	session.update(&FetchResponse{SessionID: 42})
+
+	// only the partition which moved is listed
+	request = build(map[int32]int64{0: 11, 1: 20})
+	if request.SessionID != 42 || request.SessionEpoch != 1 {
+		t.Errorf("expected session 42 at epoch 1, got id %d epoch %d", request.SessionID, request.SessionEpoch)
+	}
+	if offsets := requested(request); !reflect.DeepEqual(offsets, map[int32]int64{0: 11}) {
+		t.Errorf("expected only partition 0, got %v", offsets)
+	}
+	if len(request.forgotten) != 0 {
+		t.Errorf("expected no forgotten partitions, got %v", request.forgotten)
+	}
+	session.update(&FetchResponse{SessionID: 42})
+
+	// a partition no longer fetched is removed from the session
+	request = build(map[int32]int64{0: 11})
+	if request.SessionEpoch != 2 || len(request.blocks) != 0 {
+		t.Errorf("expected an empty request at epoch 2, got epoch %d with %v", request.SessionEpoch, request.blocks)
+	}
+	if !reflect.DeepEqual(request.forgotten, map[string][]int32{"my_topic": {1}}) {
+		t.Errorf("expected partition 1 to be forgotten, got %v", request.forgotten)
+	}
+
+	// the broker evicted the session, the next request starts over
+	session.update(&FetchResponse{ErrorCode: int16(ErrFetchSessionIDNotFound)})
+	request = build(map[int32]int64{0: 11})
+	if request.SessionID != 0 || request.SessionEpoch != 0 {
+		t.Errorf("expected a new session, got id %d epoch %d", request.SessionID, request.SessionEpoch)
+	}
+	if offsets := requested(request); !reflect.DeepEqual(offsets, map[int32]int64{0: 11}) {
+		t.Errorf("expected partition 0, got %v", offsets)
+	}
+}
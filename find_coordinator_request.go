@@ -51,6 +51,10 @@ func (f *FindCoordinatorRequest) version() int16 {
 	return f.Version
 }
 
+func (f *FindCoordinatorRequest) setVersion(v int16) {
+	f.Version = v
+}
+
 func (r *FindCoordinatorRequest) headerVersion() int16 {
 	return 1
 }
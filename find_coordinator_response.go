@@ -82,6 +82,10 @@ func (f *FindCoordinatorResponse) version() int16 {
 	return f.Version
 }
 
+func (f *FindCoordinatorResponse) setVersion(v int16) {
+	f.Version = v
+}
+
 func (r *FindCoordinatorResponse) headerVersion() int16 {
 	return 0
 }
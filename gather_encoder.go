@@ -0,0 +1,212 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"net"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// gatherThreshold is the size from which byte slices are referenced by the
+// gather encoder instead of being copied into the request buffer. Smaller
+// slices are cheaper to copy than to hand to the kernel as a separate buffer.
+const gatherThreshold = 4096
+
+// gatheringEncoder is implemented by the packet encoders which reference
+// large byte slices instead of copying them, so that record batches know to
+// encode their records in place rather than into an intermediate buffer.
+type gatheringEncoder interface {
+	packetEncoder
+	gathers() bool
+}
+
+func isGathering(pe packetEncoder) bool {
+	ge, ok := pe.(gatheringEncoder)
+	return ok && ge.gathers()
+}
+
+// gatherPrepEncoder is the sizing pass of the gather encoder: it computes the
+// length of the encoded packet and how much of it is made of referenced
+// slices, which do not need room in the request buffer.
+type gatherPrepEncoder struct {
+	prepEncoder
+	gathered int
+}
+
+func (pe *gatherPrepEncoder) gathers() bool {
+	return true
+}
+
+func (pe *gatherPrepEncoder) putBytes(in []byte) error {
+	pe.count(in)
+	return pe.prepEncoder.putBytes(in)
+}
+
+func (pe *gatherPrepEncoder) putVarintBytes(in []byte) error {
+	pe.count(in)
+	return pe.prepEncoder.putVarintBytes(in)
+}
+
+func (pe *gatherPrepEncoder) putRawBytes(in []byte) error {
+	pe.count(in)
+	return pe.prepEncoder.putRawBytes(in)
+}
+
+func (pe *gatherPrepEncoder) count(in []byte) {
+	if len(in) >= gatherThreshold {
+		pe.gathered += len(in)
+	}
+}
+
+// gatherSegment is a referenced slice, to be written after the first at bytes
+// of the request buffer.
+type gatherSegment struct {
+	at   int
+	data []byte
+}
+
+// gatherMark records the segments which existed when a field was pushed, so
+// that the field can account for the segments encoded after it.
+type gatherMark struct {
+	segments int
+	gathered int
+}
+
+// gatherEncoder is a realEncoder which leaves large byte slices out of its
+// buffer and references them as segments instead. Offsets into raw exclude
+// the segments, so length fields are given the logical offset of the packet
+// and CRCs are computed over the buffer and the segments in order.
+type gatherEncoder struct {
+	realEncoder
+	segments []gatherSegment
+	gathered int
+	marks    []gatherMark
+}
+
+func (ge *gatherEncoder) gathers() bool {
+	return true
+}
+
+func (ge *gatherEncoder) putBytes(in []byte) error {
+	if len(in) < gatherThreshold {
+		return ge.realEncoder.putBytes(in)
+	}
+	ge.putInt32(int32(len(in)))
+	ge.gather(in)
+	return nil
+}
+
+func (ge *gatherEncoder) putVarintBytes(in []byte) error {
+	if len(in) < gatherThreshold {
+		return ge.realEncoder.putVarintBytes(in)
+	}
+	ge.putVarint(int64(len(in)))
+	ge.gather(in)
+	return nil
+}
+
+func (ge *gatherEncoder) putRawBytes(in []byte) error {
+	if len(in) < gatherThreshold {
+		return ge.realEncoder.putRawBytes(in)
+	}
+	ge.gather(in)
+	return nil
+}
+
+func (ge *gatherEncoder) gather(in []byte) {
+	ge.segments = append(ge.segments, gatherSegment{at: ge.off, data: in})
+	ge.gathered += len(in)
+}
+
+func (ge *gatherEncoder) offset() int {
+	return ge.off + ge.gathered
+}
+
+func (ge *gatherEncoder) push(in pushEncoder) {
+	ge.realEncoder.push(in)
+	ge.marks = append(ge.marks, gatherMark{segments: len(ge.segments), gathered: ge.gathered})
+}
+
+func (ge *gatherEncoder) pop() error {
+	mark := ge.marks[len(ge.marks)-1]
+	ge.marks = ge.marks[:len(ge.marks)-1]
+	in := ge.stack[len(ge.stack)-1]
+	ge.stack = ge.stack[:len(ge.stack)-1]
+
+	if crc, ok := in.(*crc32Field); ok {
+		return ge.runCRC(crc, ge.segments[mark.segments:])
+	}
+	return in.run(ge.off+ge.gathered-mark.gathered, ge.raw)
+}
+
+func (ge *gatherEncoder) runCRC(c *crc32Field, segments []gatherSegment) error {
+	tab, err := c.table()
+	if err != nil {
+		return err
+	}
+	var crc uint32
+	start := c.startOffset + 4
+	for _, segment := range segments {
+		crc = crc32.Update(crc, tab, ge.raw[start:segment.at])
+		crc = crc32.Update(crc, tab, segment.data)
+		start = segment.at
+	}
+	crc = crc32.Update(crc, tab, ge.raw[start:ge.off])
+	binary.BigEndian.PutUint32(ge.raw[c.startOffset:], crc)
+	return nil
+}
+
+// buffers returns the encoded packet, interleaving the request buffer with
+// the referenced segments.
+func (ge *gatherEncoder) buffers() net.Buffers {
+	bufs := make(net.Buffers, 0, 2*len(ge.segments)+1)
+	start := 0
+	for _, segment := range ge.segments {
+		if segment.at > start {
+			bufs = append(bufs, ge.raw[start:segment.at])
+		}
+		bufs = append(bufs, segment.data)
+		start = segment.at
+	}
+	if len(ge.raw) > start {
+		bufs = append(bufs, ge.raw[start:])
+	}
+	return bufs
+}
+
+// encodeGather encodes like encode, but references the large byte slices of
+// the encoder, such as the values of uncompressed records, instead of copying
+// them. The returned buffers must be written in order before those slices are
+// modified.
+func encodeGather(e encoder, metricRegistry metrics.Registry) (net.Buffers, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	var prepEnc gatherPrepEncoder
+	var gatherEnc gatherEncoder
+
+	err := e.encode(&prepEnc)
+	if err != nil {
+		return nil, err
+	}
+
+	if prepEnc.length < 0 || prepEnc.length > int(MaxRequestSize) {
+		return nil, PacketEncodingError{fmt.Sprintf("invalid request size (%d)", prepEnc.length)}
+	}
+
+	gatherEnc.raw = make([]byte, prepEnc.length-prepEnc.gathered)
+	gatherEnc.registry = metricRegistry
+	err = e.encode(&gatherEnc)
+	if err != nil {
+		return nil, err
+	}
+
+	if gatherEnc.offset() != prepEnc.length {
+		return nil, PacketEncodingError{fmt.Sprintf("encoded %d bytes but sizing pass computed %d", gatherEnc.offset(), prepEnc.length)}
+	}
+
+	return gatherEnc.buffers(), nil
+}
@@ -0,0 +1,132 @@
+package sarama
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func gatherTestProduceRequest(large []byte) *ProduceRequest {
+	batch := &RecordBatch{
+		Version:        2,
+		FirstTimestamp: time.Unix(1234, 0),
+		MaxTimestamp:   time.Unix(1234, 0),
+		Records: []*Record{
+			{Key: []byte("small"), Value: []byte("value")},
+			{OffsetDelta: 1, Key: []byte("large"), Value: large, Headers: []*RecordHeader{{Key: []byte("h"), Value: []byte("v")}}},
+			{OffsetDelta: 2, Value: large},
+		},
+		LastOffsetDelta: 2,
+	}
+	req := &ProduceRequest{Version: 3, RequiredAcks: WaitForAll, Timeout: 100}
+	req.AddBatch("my_topic", 0, batch)
+	return req
+}
+
+func TestEncodeGatherMatchesEncode(t *testing.T) {
+	for name, req := range map[string]encoder{
+		"record batch": &request{correlationID: 7, clientID: "gather", body: gatherTestProduceRequest(bytes.Repeat([]byte{'v'}, 3*gatherThreshold))},
+		"message set": &request{correlationID: 7, clientID: "gather", body: func() *ProduceRequest {
+			req := &ProduceRequest{Version: 2, RequiredAcks: WaitForLocal, Timeout: 100}
+			req.AddMessage("my_topic", 0, &Message{Version: 1, Key: []byte("key"), Value: bytes.Repeat([]byte{'v'}, gatherThreshold)})
+			req.AddMessage("my_topic", 0, &Message{Version: 1, Value: []byte("small")})
+			return req
+		}()},
+		"nothing to gather": &request{correlationID: 7, clientID: "gather", body: gatherTestProduceRequest([]byte("tiny"))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// gather first, encoding caches the records of the batch
+			bufs, err := encodeGather(req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := encode(req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := bytes.Join(bufs, nil); !bytes.Equal(actual, expected) {
+				t.Errorf("gathered encoding differs from the copying encoding:\n%v\n%v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestEncodeGatherReferencesValues(t *testing.T) {
+	value := bytes.Repeat([]byte{'v'}, 2*gatherThreshold)
+	req := gatherTestProduceRequest(value)
+
+	bufs, err := encodeGather(&request{body: req}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	referenced := 0
+	for _, buf := range bufs {
+		if &buf[0] == &value[0] {
+			referenced++
+		}
+	}
+	// both large records share the same value slice
+	if referenced != 2 {
+		t.Errorf("expected the value to be referenced twice, got %d out of %d buffers", referenced, len(bufs))
+	}
+}
+
+func BenchmarkProduceRequestWrite(b *testing.B) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, size := range []int{64 << 10, 1 << 20} {
+		value := make([]byte, size)
+		length := int64(2 * size)
+
+		b.Run(fmt.Sprintf("copy/%dKiB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(length)
+			for i := 0; i < b.N; i++ {
+				req := &request{clientID: "bench", body: gatherTestProduceRequest(value)}
+				buf, err := encode(req, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := conn.Write(buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("gather/%dKiB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(length)
+			for i := 0; i < b.N; i++ {
+				req := &request{clientID: "bench", body: gatherTestProduceRequest(value)}
+				bufs, err := encodeGather(req, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := bufs.WriteTo(conn); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -56,6 +56,10 @@ func (r *HeartbeatRequest) version() int16 {
 	return r.Version
 }
 
+func (r *HeartbeatRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *HeartbeatRequest) headerVersion() int16 {
 	return 1
 }
@@ -78,3 +82,11 @@ func (r *HeartbeatRequest) requiredVersion() KafkaVersion {
 		return V2_3_0_0
 	}
 }
+
+// fieldsVersion returns 3 when GroupInstanceId is set for static membership.
+func (r *HeartbeatRequest) fieldsVersion() int16 {
+	if r.GroupInstanceId != nil {
+		return 3
+	}
+	return 0
+}
@@ -58,6 +58,10 @@ func (a *IncrementalAlterConfigsResponse) version() int16 {
 	return a.Version
 }
 
+func (a *IncrementalAlterConfigsResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *IncrementalAlterConfigsResponse) headerVersion() int16 {
 	return 0
 }
@@ -76,6 +76,10 @@ func (i *InitProducerIDRequest) version() int16 {
 	return i.Version
 }
 
+func (i *InitProducerIDRequest) setVersion(v int16) {
+	i.Version = v
+}
+
 func (i *InitProducerIDRequest) headerVersion() int16 {
 	if i.Version >= 2 {
 		return 2
@@ -164,6 +164,10 @@ func (r *JoinGroupRequest) version() int16 {
 	return r.Version
 }
 
+func (r *JoinGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *JoinGroupRequest) headerVersion() int16 {
 	return 1
 }
@@ -191,6 +195,14 @@ func (r *JoinGroupRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns 5 when GroupInstanceId is set for static membership.
+func (r *JoinGroupRequest) fieldsVersion() int16 {
+	if r.GroupInstanceId != nil {
+		return 5
+	}
+	return 0
+}
+
 func (r *JoinGroupRequest) AddGroupProtocol(name string, metadata []byte) {
 	r.OrderedGroupProtocols = append(r.OrderedGroupProtocols, &GroupProtocol{
 		Name:     name,
@@ -77,6 +77,10 @@ func (r *LeaveGroupRequest) version() int16 {
 	return r.Version
 }
 
+func (r *LeaveGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *LeaveGroupRequest) headerVersion() int16 {
 	return 1
 }
@@ -99,3 +103,12 @@ func (r *LeaveGroupRequest) requiredVersion() KafkaVersion {
 		return V2_4_0_0
 	}
 }
+
+// fieldsVersion returns 3 when the request lists Members, older versions only
+// take a single MemberId.
+func (r *LeaveGroupRequest) fieldsVersion() int16 {
+	if len(r.Members) > 0 {
+		return 3
+	}
+	return 0
+}
@@ -53,6 +53,10 @@ func (r *ListGroupsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ListGroupsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ListGroupsRequest) headerVersion() int16 {
 	if r.Version >= 3 {
 		return 2
@@ -144,6 +144,10 @@ func (r *ListGroupsResponse) version() int16 {
 	return r.Version
 }
 
+func (r *ListGroupsResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ListGroupsResponse) headerVersion() int16 {
 	if r.Version >= 3 {
 		return 1
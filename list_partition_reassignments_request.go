@@ -79,6 +79,10 @@ func (r *ListPartitionReassignmentsRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ListPartitionReassignmentsRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ListPartitionReassignmentsRequest) headerVersion() int16 {
 	return 2
 }
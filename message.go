@@ -169,12 +169,12 @@ func (m *Message) decode(pd packetDecoder) (err error) {
 	m.compressedSize = len(m.Value)
 
 	if m.Value != nil && m.Codec != CompressionNone {
-		m.Value, err = decompress(m.Codec, m.Value)
+		m.Value, err = decompress(m.Codec, m.Value, pd.lenientDecompression())
 		if err != nil {
 			return err
 		}
 
-		if err := m.decodeSet(); err != nil {
+		if err := m.decodeSet(pd.lenientDecompression()); err != nil {
 			return err
 		}
 	}
@@ -183,8 +183,8 @@ func (m *Message) decode(pd packetDecoder) (err error) {
 }
 
 // decodes a message set from a previously encoded bulk-message
-func (m *Message) decodeSet() (err error) {
-	pd := realDecoder{raw: m.Value}
+func (m *Message) decodeSet(lenient bool) (err error) {
+	pd := realDecoder{raw: m.Value, lenient: lenient}
 	m.Set = &MessageSet{}
 	return m.Set.decode(&pd)
 }
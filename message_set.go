@@ -97,6 +97,8 @@ func (ms *MessageSet) decode(pd packetDecoder) (err error) {
 				ms.PartialTrailingMessage = true
 			}
 			return nil
+		} else if errors.Is(err, ErrCRCMismatch) && !errors.As(err, new(CorruptRecordsError)) {
+			return CorruptRecordsError{Offset: msb.Offset, Err: err}
 		} else {
 			return err
 		}
@@ -201,6 +201,10 @@ func (r *MetadataRequest) version() int16 {
 	return r.Version
 }
 
+func (r *MetadataRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *MetadataRequest) headerVersion() int16 {
 	if r.Version >= 9 {
 		return 2
@@ -238,3 +242,15 @@ func (r *MetadataRequest) requiredVersion() KafkaVersion {
 		return V2_8_0_0
 	}
 }
+
+// fieldsVersion returns the version needed by the authorized operations and
+// by disabling the auto-creation of topics, which older brokers always do.
+func (r *MetadataRequest) fieldsVersion() int16 {
+	switch {
+	case r.IncludeClusterAuthorizedOperations || r.IncludeTopicAuthorizedOperations:
+		return 8
+	case !r.AllowAutoTopicCreation:
+		return 4
+	}
+	return 0
+}
@@ -276,6 +276,50 @@ func (mor *MockOffsetResponse) getOffset(topic string, partition int32, time int
 	return offset
 }
 
+// MockOffsetForLeaderEpochResponse is an `OffsetForLeaderEpochResponse` builder.
+type MockOffsetForLeaderEpochResponse struct {
+	endOffsets map[string]map[int32]map[int32]int64
+	t          TestReporter
+}
+
+func NewMockOffsetForLeaderEpochResponse(t TestReporter) *MockOffsetForLeaderEpochResponse {
+	return &MockOffsetForLeaderEpochResponse{
+		endOffsets: make(map[string]map[int32]map[int32]int64),
+		t:          t,
+	}
+}
+
+func (mr *MockOffsetForLeaderEpochResponse) SetEndOffset(topic string, partition, leaderEpoch int32, endOffset int64) *MockOffsetForLeaderEpochResponse {
+	partitions := mr.endOffsets[topic]
+	if partitions == nil {
+		partitions = make(map[int32]map[int32]int64)
+		mr.endOffsets[topic] = partitions
+	}
+	epochs := partitions[partition]
+	if epochs == nil {
+		epochs = make(map[int32]int64)
+		partitions[partition] = epochs
+	}
+	epochs[leaderEpoch] = endOffset
+	return mr
+}
+
+func (mr *MockOffsetForLeaderEpochResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*OffsetForLeaderEpochRequest)
+	res := &OffsetForLeaderEpochResponse{Version: req.Version}
+	for topic, partitions := range req.blocks {
+		for partition, block := range partitions {
+			endOffset, ok := mr.endOffsets[topic][partition][block.leaderEpoch]
+			if !ok {
+				res.AddBlock(topic, partition, ErrNoError, invalidLeaderEpoch, -1)
+				continue
+			}
+			res.AddBlock(topic, partition, ErrNoError, block.leaderEpoch, endOffset)
+		}
+	}
+	return res
+}
+
 // mockMessage is a message that used to be mocked for `FetchResponse`
 type mockMessage struct {
 	key Encoder
@@ -756,6 +800,27 @@ func (mr *MockAlterPartitionReassignmentsResponse) For(reqBody versionedDecoder)
 	return res
 }
 
+type MockElectLeadersResponse struct {
+	t TestReporter
+}
+
+func NewMockElectLeadersResponse(t TestReporter) *MockElectLeadersResponse {
+	return &MockElectLeadersResponse{t: t}
+}
+
+func (mr *MockElectLeadersResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*ElectLeadersRequest)
+	res := &ElectLeadersResponse{Version: req.version()}
+
+	for topic, partitions := range req.TopicPartitions {
+		for _, partition := range partitions {
+			res.AddResult(topic, partition, ErrNoError, nil)
+		}
+	}
+
+	return res
+}
+
 type MockListPartitionReassignmentsResponse struct {
 	t TestReporter
 }
@@ -1378,6 +1443,7 @@ func (m *MockHeartbeatResponse) For(reqBody versionedDecoder) encoderWithHeader
 	req := reqBody.(*HeartbeatRequest)
 	resp := &HeartbeatResponse{
 		Version: req.version(),
+		Err:     m.Err,
 	}
 	return resp
 }
@@ -67,6 +67,30 @@ func (c *Consumer) ConsumePartition(topic string, partition int32, offset int64)
 	return pc, nil
 }
 
+// ConsumePartitionUntil implements the ConsumePartitionUntil method from the sarama.BoundedConsumer interface.
+// Expectations are set using ExpectConsumePartition, just like for ConsumePartition. Messages yielded
+// at or beyond endOffset are dropped, and the Messages channel is closed once the message right
+// before endOffset has been yielded. Like the real consumer, endOffset must be a literal offset not
+// smaller than a literal starting offset.
+func (c *Consumer) ConsumePartitionUntil(topic string, partition int32, offset, endOffset int64) (sarama.PartitionConsumer, error) {
+	if endOffset < 0 {
+		return nil, sarama.ConfigurationError("endOffset must be a literal offset")
+	}
+	if offset >= 0 && endOffset < offset {
+		return nil, sarama.ConfigurationError("endOffset must not be smaller than the starting offset")
+	}
+
+	pc, err := c.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	mpc := pc.(*PartitionConsumer)
+	mpc.applyEndOffset(endOffset)
+
+	return mpc, nil
+}
+
 // Topics returns a list of topics, as registered with SetTopicMetadata
 func (c *Consumer) Topics() ([]string, error) {
 	c.l.Lock()
@@ -253,6 +277,8 @@ type PartitionConsumer struct {
 	topic                         string
 	partition                     int32
 	offset                        int64
+	endOffset                     int64
+	bounded                       bool
 	messages                      chan *sarama.ConsumerMessage
 	suppressedMessages            chan *sarama.ConsumerMessage
 	suppressedHighWaterMarkOffset int64
@@ -380,6 +406,32 @@ func (pc *PartitionConsumer) IsPaused() bool {
 	return pc.paused
 }
 
+// applyEndOffset bounds the partition consumer, dropping any already yielded
+// messages at or beyond endOffset.
+func (pc *PartitionConsumer) applyEndOffset(endOffset int64) {
+	pc.l.Lock()
+	defer pc.l.Unlock()
+
+	pc.bounded = true
+	pc.endOffset = endOffset
+
+	reachedEnd := false
+	for n := len(pc.messages); n > 0; n-- {
+		msg := <-pc.messages
+		if msg.Offset >= endOffset {
+			continue
+		}
+		if msg.Offset+1 >= endOffset {
+			reachedEnd = true
+		}
+		pc.messages <- msg
+	}
+
+	if reachedEnd {
+		pc.AsyncClose()
+	}
+}
+
 ///////////////////////////////////////////////////
 // Expectation API
 ///////////////////////////////////////////////////
@@ -398,10 +450,19 @@ func (pc *PartitionConsumer) YieldMessage(msg *sarama.ConsumerMessage) *Partitio
 
 	if pc.paused {
 		msg.Offset = atomic.AddInt64(&pc.suppressedHighWaterMarkOffset, 1) - 1
+		if pc.bounded && msg.Offset >= pc.endOffset {
+			return pc
+		}
 		pc.suppressedMessages <- msg
 	} else {
 		msg.Offset = atomic.AddInt64(&pc.highWaterMarkOffset, 1) - 1
+		if pc.bounded && msg.Offset >= pc.endOffset {
+			return pc
+		}
 		pc.messages <- msg
+		if pc.bounded && msg.Offset+1 >= pc.endOffset {
+			pc.AsyncClose()
+		}
 	}
 
 	return pc
@@ -14,6 +14,9 @@ func TestMockConsumerImplementsConsumerInterface(t *testing.T) {
 	if _, ok := c.(sarama.Consumer); !ok {
 		t.Error("The mock consumer should implement the sarama.Consumer interface.")
 	}
+	if _, ok := c.(sarama.BoundedConsumer); !ok {
+		t.Error("The mock consumer should implement the sarama.BoundedConsumer interface.")
+	}
 
 	var pc interface{} = &PartitionConsumer{}
 	if _, ok := pc.(sarama.PartitionConsumer); !ok {
@@ -66,6 +69,57 @@ func TestConsumerHandlesExpectations(t *testing.T) {
 	}
 }
 
+func TestConsumerConsumePartitionUntil(t *testing.T) {
+	consumer := NewConsumer(t, NewTestConfig())
+	defer func() {
+		if err := consumer.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	pcmock := consumer.ExpectConsumePartition("test", 0, sarama.OffsetOldest)
+	for i := 0; i < 5; i++ {
+		pcmock.YieldMessage(&sarama.ConsumerMessage{Value: []byte("hello")})
+	}
+
+	pc, err := consumer.ConsumePartitionUntil("test", 0, sarama.OffsetOldest, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offsets []int64
+	for msg := range pc.Messages() {
+		offsets = append(offsets, msg.Offset)
+	}
+	if len(offsets) != 3 || offsets[0] != 0 || offsets[2] != 2 {
+		t.Errorf("Expected offsets [0 1 2], got %v", offsets)
+	}
+}
+
+func TestConsumerConsumePartitionUntilInvalidEndOffset(t *testing.T) {
+	consumer := NewConsumer(t, NewTestConfig())
+	defer func() {
+		if err := consumer.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	consumer.ExpectConsumePartition("test", 0, AnyOffset)
+
+	var cfgErr sarama.ConfigurationError
+	if _, err := consumer.ConsumePartitionUntil("test", 0, sarama.OffsetOldest, sarama.OffsetNewest); !errors.As(err, &cfgErr) {
+		t.Errorf("Expected a ConfigurationError for a negative endOffset, got %v", err)
+	}
+	if _, err := consumer.ConsumePartitionUntil("test", 0, 10, 5); !errors.As(err, &cfgErr) {
+		t.Errorf("Expected a ConfigurationError for an endOffset before the starting offset, got %v", err)
+	}
+
+	// the rejected calls did not consume the partition
+	if _, err := consumer.ConsumePartitionUntil("test", 0, 0, 5); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestConsumerHandlesExpectationsPausingResuming(t *testing.T) {
 	consumer := NewConsumer(t, NewTestConfig())
 	defer func() {
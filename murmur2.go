@@ -0,0 +1,75 @@
+package sarama
+
+import "hash"
+
+// murmur2 implements hash.Hash32 for the variant of MurmurHash2 used by the
+// Java client to partition keyed records (org.apache.kafka.common.utils.Utils.murmur2).
+// The hash is computed over all the bytes written since the last Reset.
+type murmur2 struct {
+	data []byte
+}
+
+func newMurmur2() hash.Hash32 {
+	return new(murmur2)
+}
+
+func (m *murmur2) Write(p []byte) (int, error) {
+	m.data = append(m.data, p...)
+	return len(p), nil
+}
+
+func (m *murmur2) Sum(b []byte) []byte {
+	h := m.Sum32()
+	return append(b, byte(h>>24), byte(h>>16), byte(h>>8), byte(h))
+}
+
+func (m *murmur2) Reset() {
+	m.data = m.data[:0]
+}
+
+func (m *murmur2) Size() int {
+	return 4
+}
+
+func (m *murmur2) BlockSize() int {
+	return 4
+}
+
+func (m *murmur2) Sum32() uint32 {
+	const (
+		seed = 0x9747b28c
+		mul  = 0x5bd1e995
+		r    = 24
+	)
+
+	data := m.data
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= mul
+		k ^= k >> r
+		k *= mul
+		h *= mul
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= mul
+	}
+
+	h ^= h >> 13
+	h *= mul
+	h ^= h >> 15
+	return h
+}
@@ -0,0 +1,85 @@
+package sarama
+
+import "sync"
+
+// PartitionAcker provides at-least-once processing on top of a
+// PartitionOffsetManager. Messages are tracked when they are handed out for
+// processing and acknowledged once the work is done, possibly out of order.
+// The offset is only marked up to the highest contiguously acknowledged
+// message, so a message which is still being processed is never committed
+// past, even if later messages have already been acknowledged.
+//
+// PartitionAcker is safe for concurrent use.
+type PartitionAcker struct {
+	pom PartitionOffsetManager
+
+	lock    sync.Mutex
+	pending []int64 // tracked offsets in delivery order
+	acked   map[int64]struct{}
+}
+
+// NewPartitionAcker creates a PartitionAcker marking offsets on the given
+// PartitionOffsetManager.
+func NewPartitionAcker(pom PartitionOffsetManager) *PartitionAcker {
+	return &PartitionAcker{
+		pom:   pom,
+		acked: make(map[int64]struct{}),
+	}
+}
+
+// Track registers a message as handed out for processing. Messages must be
+// tracked in the order they were consumed, before being acknowledged.
+func (a *PartitionAcker) Track(msg *ConsumerMessage) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.pending = append(a.pending, msg.Offset)
+}
+
+// Ack acknowledges a tracked message as processed. If it completes a
+// contiguous run of acknowledged messages starting at the oldest outstanding
+// one, the offset following that run is marked on the PartitionOffsetManager.
+// Acknowledging a message which is not tracked has no effect.
+func (a *PartitionAcker) Ack(msg *ConsumerMessage) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.isPending(msg.Offset) {
+		return
+	}
+	a.acked[msg.Offset] = struct{}{}
+
+	done := 0
+	for _, offset := range a.pending {
+		if _, ok := a.acked[offset]; !ok {
+			break
+		}
+		delete(a.acked, offset)
+		done++
+	}
+	if done == 0 {
+		return
+	}
+
+	a.pom.MarkOffset(a.pending[done-1]+1, "")
+	a.pending = a.pending[done:]
+}
+
+// Outstanding returns the number of tracked messages which have not been
+// committed yet, either because they are not acknowledged or because an
+// older message is still being processed.
+func (a *PartitionAcker) Outstanding() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return len(a.pending)
+}
+
+func (a *PartitionAcker) isPending(offset int64) bool {
+	for _, pending := range a.pending {
+		if pending == offset {
+			return true
+		}
+	}
+	return false
+}
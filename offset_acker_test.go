@@ -0,0 +1,80 @@
+package sarama
+
+import "testing"
+
+type markRecordingOffsetManager struct {
+	PartitionOffsetManager
+	marked []int64
+}
+
+func (m *markRecordingOffsetManager) MarkOffset(offset int64, metadata string) {
+	m.marked = append(m.marked, offset)
+}
+
+func TestPartitionAckerCommitsContiguousPrefix(t *testing.T) {
+	pom := &markRecordingOffsetManager{}
+	acker := NewPartitionAcker(pom)
+
+	msgs := make([]*ConsumerMessage, 5)
+	for i := range msgs {
+		msgs[i] = &ConsumerMessage{Topic: "my_topic", Partition: 0, Offset: int64(10 + i)}
+		acker.Track(msgs[i])
+	}
+
+	// acking 11 and 13 leaves 10 outstanding, nothing may be committed
+	acker.Ack(msgs[1])
+	acker.Ack(msgs[3])
+	if len(pom.marked) != 0 {
+		t.Fatalf("expected nothing to be marked while offset 10 is un-acked, got %v", pom.marked)
+	}
+
+	// acking 10 completes 10-11, 12 is still outstanding
+	acker.Ack(msgs[0])
+	if len(pom.marked) != 1 || pom.marked[0] != 12 {
+		t.Fatalf("expected offset 12 to be marked, got %v", pom.marked)
+	}
+
+	// acking 12 completes 12-13
+	acker.Ack(msgs[2])
+	if len(pom.marked) != 2 || pom.marked[1] != 14 {
+		t.Fatalf("expected offset 14 to be marked, got %v", pom.marked)
+	}
+	if n := acker.Outstanding(); n != 1 {
+		t.Errorf("expected one outstanding message, got %d", n)
+	}
+
+	// acking twice, or acking an untracked message, changes nothing
+	acker.Ack(msgs[2])
+	acker.Ack(&ConsumerMessage{Offset: 99})
+	if len(pom.marked) != 2 {
+		t.Errorf("expected no further marks, got %v", pom.marked)
+	}
+
+	acker.Ack(msgs[4])
+	if len(pom.marked) != 3 || pom.marked[2] != 15 {
+		t.Fatalf("expected offset 15 to be marked, got %v", pom.marked)
+	}
+	if n := acker.Outstanding(); n != 0 {
+		t.Errorf("expected no outstanding messages, got %d", n)
+	}
+}
+
+func TestPartitionAckerOffsetGaps(t *testing.T) {
+	pom := &markRecordingOffsetManager{}
+	acker := NewPartitionAcker(pom)
+
+	// offsets of a compacted topic are not consecutive
+	first := &ConsumerMessage{Offset: 3}
+	second := &ConsumerMessage{Offset: 7}
+	acker.Track(first)
+	acker.Track(second)
+
+	acker.Ack(second)
+	if len(pom.marked) != 0 {
+		t.Fatalf("expected nothing to be marked while offset 3 is un-acked, got %v", pom.marked)
+	}
+	acker.Ack(first)
+	if len(pom.marked) != 1 || pom.marked[0] != 8 {
+		t.Fatalf("expected offset 8 to be marked, got %v", pom.marked)
+	}
+}
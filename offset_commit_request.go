@@ -197,6 +197,10 @@ func (r *OffsetCommitRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetCommitRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetCommitRequest) headerVersion() int16 {
 	return 1
 }
@@ -224,6 +228,14 @@ func (r *OffsetCommitRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns 7 when GroupInstanceId is set for static membership.
+func (r *OffsetCommitRequest) fieldsVersion() int16 {
+	if r.GroupInstanceId != nil {
+		return 7
+	}
+	return 0
+}
+
 func (r *OffsetCommitRequest) AddBlock(topic string, partitionID int32, offset int64, timestamp int64, metadata string) {
 	r.AddBlockWithLeaderEpoch(topic, partitionID, offset, 0, timestamp, metadata)
 }
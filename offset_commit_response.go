@@ -96,6 +96,10 @@ func (r *OffsetCommitResponse) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetCommitResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetCommitResponse) headerVersion() int16 {
 	return 0
 }
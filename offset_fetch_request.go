@@ -200,6 +200,10 @@ func (r *OffsetFetchRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetFetchRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetFetchRequest) headerVersion() int16 {
 	if r.Version >= 6 {
 		return 2
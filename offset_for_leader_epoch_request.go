@@ -0,0 +1,150 @@
+package sarama
+
+type offsetForLeaderEpochRequestBlock struct {
+	currentLeaderEpoch int32 // version 2 or later
+	leaderEpoch        int32
+}
+
+func (b *offsetForLeaderEpochRequestBlock) encode(pe packetEncoder, version int16) error {
+	if version >= 2 {
+		pe.putInt32(b.currentLeaderEpoch)
+	}
+	pe.putInt32(b.leaderEpoch)
+	return nil
+}
+
+func (b *offsetForLeaderEpochRequestBlock) decode(pd packetDecoder, version int16) (err error) {
+	if version >= 2 {
+		if b.currentLeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+	b.leaderEpoch, err = pd.getInt32()
+	return err
+}
+
+// OffsetForLeaderEpochRequest (API key 23) asks the leader of partitions for
+// the end offset of a leader epoch, which consumers use to detect that the log
+// was truncated below the records they consumed (KIP-320).
+type OffsetForLeaderEpochRequest struct {
+	Version   int16
+	ReplicaID int32 // version 3 or later, -1 for consumers
+	blocks    map[string]map[int32]*offsetForLeaderEpochRequestBlock
+}
+
+func (r *OffsetForLeaderEpochRequest) encode(pe packetEncoder) error {
+	if r.Version >= 3 {
+		pe.putInt32(r.ReplicaID)
+	}
+
+	if err := pe.putArrayLength(len(r.blocks)); err != nil {
+		return err
+	}
+	for topic, partitions := range r.blocks {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putArrayLength(len(partitions)); err != nil {
+			return err
+		}
+		for partition, block := range partitions {
+			pe.putInt32(partition)
+			if err := block.encode(pe, r.Version); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *OffsetForLeaderEpochRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	if r.Version >= 3 {
+		if r.ReplicaID, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	topicCount, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if topicCount > 0 {
+		r.blocks = make(map[string]map[int32]*offsetForLeaderEpochRequestBlock, topicCount)
+	}
+	for i := 0; i < topicCount; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		partitionCount, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		r.blocks[topic] = make(map[int32]*offsetForLeaderEpochRequestBlock, partitionCount)
+		for j := 0; j < partitionCount; j++ {
+			partition, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+			block := new(offsetForLeaderEpochRequestBlock)
+			if err := block.decode(pd, r.Version); err != nil {
+				return err
+			}
+			r.blocks[topic][partition] = block
+		}
+	}
+
+	return nil
+}
+
+func (r *OffsetForLeaderEpochRequest) key() int16 {
+	return 23
+}
+
+func (r *OffsetForLeaderEpochRequest) version() int16 {
+	return r.Version
+}
+
+func (r *OffsetForLeaderEpochRequest) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *OffsetForLeaderEpochRequest) headerVersion() int16 {
+	return 1
+}
+
+func (r *OffsetForLeaderEpochRequest) isValidVersion() bool {
+	return r.Version >= 0 && r.Version <= 3
+}
+
+func (r *OffsetForLeaderEpochRequest) requiredVersion() KafkaVersion {
+	switch r.Version {
+	case 3:
+		return V2_3_0_0
+	case 2:
+		return V2_1_0_0
+	case 1:
+		return V2_0_0_0
+	default:
+		return V0_11_0_0
+	}
+}
+
+// AddBlock asks for the end offset of leaderEpoch in the partition, of which
+// currentLeaderEpoch is the leader epoch known to the client.
+func (r *OffsetForLeaderEpochRequest) AddBlock(topic string, partitionID int32, currentLeaderEpoch, leaderEpoch int32) {
+	if r.blocks == nil {
+		r.blocks = make(map[string]map[int32]*offsetForLeaderEpochRequestBlock)
+	}
+
+	if r.blocks[topic] == nil {
+		r.blocks[topic] = make(map[int32]*offsetForLeaderEpochRequestBlock)
+	}
+
+	r.blocks[topic][partitionID] = &offsetForLeaderEpochRequestBlock{
+		currentLeaderEpoch: currentLeaderEpoch,
+		leaderEpoch:        leaderEpoch,
+	}
+}
@@ -0,0 +1,33 @@
+package sarama
+
+import "testing"
+
+var (
+	offsetForLeaderEpochRequestV0 = []byte{
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 1, // 1 partition
+		0, 0, 0, 1, // partition 1
+		0, 0, 0, 5, // leader epoch 5
+	}
+
+	offsetForLeaderEpochRequestV3 = []byte{
+		255, 255, 255, 255, // replica ID -1
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 1, // 1 partition
+		0, 0, 0, 1, // partition 1
+		0, 0, 0, 6, // current leader epoch 6
+		0, 0, 0, 5, // leader epoch 5
+	}
+)
+
+func TestOffsetForLeaderEpochRequest(t *testing.T) {
+	request := new(OffsetForLeaderEpochRequest)
+	request.AddBlock("topic", 1, 0, 5)
+	testRequest(t, "v0", request, offsetForLeaderEpochRequestV0)
+
+	request = &OffsetForLeaderEpochRequest{Version: 3, ReplicaID: -1}
+	request.AddBlock("topic", 1, 6, 5)
+	testRequest(t, "v3", request, offsetForLeaderEpochRequestV3)
+}
@@ -0,0 +1,165 @@
+package sarama
+
+import "time"
+
+type OffsetForLeaderEpochResponseBlock struct {
+	Err KError
+	// LeaderEpoch is the epoch whose end offset is returned, the requested one
+	// or the largest epoch before it which the leader knows about (version 1
+	// or later).
+	LeaderEpoch int32
+	// EndOffset is the offset following the last record of the epoch, or -1
+	// if the epoch is unknown.
+	EndOffset int64
+}
+
+type OffsetForLeaderEpochResponse struct {
+	Version        int16
+	ThrottleTimeMs int32 // version 2 or later
+	Blocks         map[string]map[int32]*OffsetForLeaderEpochResponseBlock
+}
+
+func (r *OffsetForLeaderEpochResponse) encode(pe packetEncoder) error {
+	if r.Version >= 2 {
+		pe.putInt32(r.ThrottleTimeMs)
+	}
+
+	if err := pe.putArrayLength(len(r.Blocks)); err != nil {
+		return err
+	}
+	for topic, partitions := range r.Blocks {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putArrayLength(len(partitions)); err != nil {
+			return err
+		}
+		for partition, block := range partitions {
+			pe.putInt16(int16(block.Err))
+			pe.putInt32(partition)
+			if r.Version >= 1 {
+				pe.putInt32(block.LeaderEpoch)
+			}
+			pe.putInt64(block.EndOffset)
+		}
+	}
+
+	return nil
+}
+
+func (r *OffsetForLeaderEpochResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+	if r.Version >= 2 {
+		if r.ThrottleTimeMs, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	topicCount, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if topicCount > 0 {
+		r.Blocks = make(map[string]map[int32]*OffsetForLeaderEpochResponseBlock, topicCount)
+	}
+	for i := 0; i < topicCount; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		partitionCount, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		r.Blocks[topic] = make(map[int32]*OffsetForLeaderEpochResponseBlock, partitionCount)
+		for j := 0; j < partitionCount; j++ {
+			block := new(OffsetForLeaderEpochResponseBlock)
+			kerr, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			block.Err = KError(kerr)
+			partition, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+			block.LeaderEpoch = invalidLeaderEpoch
+			if r.Version >= 1 {
+				if block.LeaderEpoch, err = pd.getInt32(); err != nil {
+					return err
+				}
+			}
+			if block.EndOffset, err = pd.getInt64(); err != nil {
+				return err
+			}
+			r.Blocks[topic][partition] = block
+		}
+	}
+
+	return nil
+}
+
+func (r *OffsetForLeaderEpochResponse) GetBlock(topic string, partition int32) *OffsetForLeaderEpochResponseBlock {
+	if r.Blocks == nil {
+		return nil
+	}
+
+	if r.Blocks[topic] == nil {
+		return nil
+	}
+
+	return r.Blocks[topic][partition]
+}
+
+func (r *OffsetForLeaderEpochResponse) AddBlock(topic string, partition int32, err KError, leaderEpoch int32, endOffset int64) {
+	if r.Blocks == nil {
+		r.Blocks = make(map[string]map[int32]*OffsetForLeaderEpochResponseBlock)
+	}
+
+	if r.Blocks[topic] == nil {
+		r.Blocks[topic] = make(map[int32]*OffsetForLeaderEpochResponseBlock)
+	}
+
+	r.Blocks[topic][partition] = &OffsetForLeaderEpochResponseBlock{
+		Err:         err,
+		LeaderEpoch: leaderEpoch,
+		EndOffset:   endOffset,
+	}
+}
+
+func (r *OffsetForLeaderEpochResponse) key() int16 {
+	return 23
+}
+
+func (r *OffsetForLeaderEpochResponse) version() int16 {
+	return r.Version
+}
+
+func (r *OffsetForLeaderEpochResponse) setVersion(v int16) {
+	r.Version = v
+}
+
+func (r *OffsetForLeaderEpochResponse) headerVersion() int16 {
+	return 0
+}
+
+func (r *OffsetForLeaderEpochResponse) isValidVersion() bool {
+	return r.Version >= 0 && r.Version <= 3
+}
+
+func (r *OffsetForLeaderEpochResponse) requiredVersion() KafkaVersion {
+	switch r.Version {
+	case 3:
+		return V2_3_0_0
+	case 2:
+		return V2_1_0_0
+	case 1:
+		return V2_0_0_0
+	default:
+		return V0_11_0_0
+	}
+}
+
+func (r *OffsetForLeaderEpochResponse) throttleTime() time.Duration {
+	return time.Duration(r.ThrottleTimeMs) * time.Millisecond
+}
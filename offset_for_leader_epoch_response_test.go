@@ -0,0 +1,45 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	offsetForLeaderEpochResponseV0 = []byte{
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 1, // 1 partition
+		0, 0, // no error
+		0, 0, 0, 1, // partition 1
+		0, 0, 0, 0, 0, 0, 0, 100, // end offset 100
+	}
+
+	offsetForLeaderEpochResponseV3 = []byte{
+		0, 0, 0, 10, // ThrottleTimeMs 10
+		0, 0, 0, 1, // 1 topic
+		0, 5, 116, 111, 112, 105, 99, // topic name "topic"
+		0, 0, 0, 1, // 1 partition
+		0, 74, // ErrFencedLeaderEpoch
+		0, 0, 0, 1, // partition 1
+		0, 0, 0, 5, // leader epoch 5
+		255, 255, 255, 255, 255, 255, 255, 255, // end offset -1
+	}
+)
+
+func TestOffsetForLeaderEpochResponse(t *testing.T) {
+	response := new(OffsetForLeaderEpochResponse)
+	response.AddBlock("topic", 1, ErrNoError, invalidLeaderEpoch, 100)
+	testResponse(t, "v0", response, offsetForLeaderEpochResponseV0)
+
+	response = &OffsetForLeaderEpochResponse{Version: 3, ThrottleTimeMs: 10}
+	response.AddBlock("topic", 1, ErrFencedLeaderEpoch, 5, -1)
+	testResponse(t, "v3", response, offsetForLeaderEpochResponseV3)
+
+	decoded := new(OffsetForLeaderEpochResponse)
+	testVersionDecodable(t, "v3", decoded, offsetForLeaderEpochResponseV3, 3)
+	block := decoded.GetBlock("topic", 1)
+	if block == nil || !errors.Is(block.Err, ErrFencedLeaderEpoch) || block.LeaderEpoch != 5 || block.EndOffset != -1 {
+		t.Errorf("unexpected block for partition 1: %+v", block)
+	}
+}
@@ -370,6 +370,7 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 			case ErrNoError:
 				block := req.blocks[pom.topic][pom.partition]
 				pom.updateCommitted(block.offset, block.metadata)
+				pom.resetLoadInProgress()
 			case ErrNotLeaderForPartition, ErrLeaderNotAvailable,
 				ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
 				// not a critical error, we just need to redispatch
@@ -378,7 +379,12 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 				// nothing we can do about this, just tell the user and carry on
 				pom.handleError(err)
 			case ErrOffsetsLoadInProgress:
-				// nothing wrong but we didn't commit, we'll get it next time round
+				// nothing wrong but we didn't commit, we'll get it next time round,
+				// unless the coordinator keeps loading for longer than we retry
+				if pom.loadInProgress() > om.conf.Consumer.Offsets.Retry.Max {
+					pom.resetLoadInProgress()
+					pom.handleError(err)
+				}
 			case ErrFencedInstancedId:
 				pom.handleError(err)
 				// TODO close the whole consumer for instance fenced....
@@ -530,6 +536,9 @@ type partitionOffsetManager struct {
 	dirty    bool
 	done     bool
 
+	// consecutive commits answered with ErrOffsetsLoadInProgress
+	loadInProgressCount int
+
 	releaseOnce sync.Once
 	errors      chan *ConsumerError
 }
@@ -586,6 +595,23 @@ func (pom *partitionOffsetManager) updateCommitted(offset int64, metadata string
 	}
 }
 
+// loadInProgress records a commit answered with ErrOffsetsLoadInProgress and
+// returns the number of consecutive such commits.
+func (pom *partitionOffsetManager) loadInProgress() int {
+	pom.lock.Lock()
+	defer pom.lock.Unlock()
+
+	pom.loadInProgressCount++
+	return pom.loadInProgressCount
+}
+
+func (pom *partitionOffsetManager) resetLoadInProgress() {
+	pom.lock.Lock()
+	defer pom.lock.Unlock()
+
+	pom.loadInProgressCount = 0
+}
+
 func (pom *partitionOffsetManager) NextOffset() (int64, string) {
 	pom.lock.Lock()
 	defer pom.lock.Unlock()
@@ -288,7 +288,7 @@ func TestOffsetManagerFetchInitialLoadInProgress(t *testing.T) {
 	defer broker.Close()
 	defer coordinator.Close()
 
-	// Error on first fetchInitialOffset call
+	// Error on the first two fetchInitialOffset calls
 	responseBlock := OffsetFetchResponseBlock{
 		Err:      ErrOffsetsLoadInProgress,
 		Offset:   5,
@@ -298,8 +298,10 @@ func TestOffsetManagerFetchInitialLoadInProgress(t *testing.T) {
 	fetchResponse := new(OffsetFetchResponse)
 	fetchResponse.AddBlock("my_topic", 0, &responseBlock)
 	coordinator.Returns(fetchResponse)
+	coordinator.Returns(fetchResponse)
+	testClient.Config().Metadata.Retry.Max = 2
 
-	// Second fetchInitialOffset call is fine
+	// Third fetchInitialOffset call is fine
 	fetchResponse2 := new(OffsetFetchResponse)
 	responseBlock2 := responseBlock
 	responseBlock2.Err = ErrNoError
@@ -308,15 +310,19 @@ func TestOffsetManagerFetchInitialLoadInProgress(t *testing.T) {
 
 	pom, err := om.ManagePartition("my_topic", 0)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+
+	if offset, meta := pom.NextOffset(); offset != 5 || meta != "test_meta" {
+		t.Errorf("Expected offset 5 and metadata test_meta once loaded, got %d and %s", offset, meta)
 	}
 
 	safeClose(t, pom)
 	safeClose(t, om)
 	safeClose(t, testClient)
 
-	if atomic.LoadInt32(&retryCount) == 0 {
-		t.Fatal("Expected at least one retry")
+	if n := atomic.LoadInt32(&retryCount); n != 2 {
+		t.Fatalf("Expected two retries, got %d", n)
 	}
 }
 
@@ -544,6 +550,75 @@ func TestPartitionOffsetManagerCommitErr(t *testing.T) {
 	safeClose(t, testClient)
 }
 
+func TestPartitionOffsetManagerCommitLoadInProgress(t *testing.T) {
+	om, testClient, broker, coordinator := initOffsetManager(t, 0)
+	defer broker.Close()
+	defer coordinator.Close()
+	pom := initPartitionOffsetManager(t, om, coordinator, 5, "meta")
+
+	// the coordinator is still loading the offsets for the first two commits
+	loading := new(OffsetCommitResponse)
+	loading.AddError("my_topic", 0, ErrOffsetsLoadInProgress)
+	coordinator.Returns(loading)
+	coordinator.Returns(loading)
+
+	committed := new(OffsetCommitResponse)
+	committed.AddError("my_topic", 0, ErrNoError)
+	coordinator.Returns(committed)
+
+	pom.MarkOffset(100, "modified_meta")
+
+	// the error is retried, not reported
+	select {
+	case err := <-pom.Errors():
+		t.Fatalf("Unexpected error %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	safeClose(t, pom)
+	safeClose(t, om)
+	safeClose(t, testClient)
+
+	commits := 0
+	for _, rr := range coordinator.History() {
+		if _, ok := rr.Request.(*OffsetCommitRequest); ok {
+			commits++
+		}
+	}
+	if commits != 3 {
+		t.Errorf("Expected three commits, got %d", commits)
+	}
+}
+
+func TestPartitionOffsetManagerCommitLoadInProgressExceedsRetries(t *testing.T) {
+	config := NewTestConfig()
+	config.Consumer.Return.Errors = true
+	om, testClient, broker, coordinator := initOffsetManagerWithBackoffFunc(t, 0, nil, config)
+	defer broker.Close()
+	defer coordinator.Close()
+	pom := initPartitionOffsetManager(t, om, coordinator, 5, "meta")
+
+	coordinator.SetHandlerByMap(map[string]MockResponse{
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t).
+			SetError("group", "my_topic", 0, ErrOffsetsLoadInProgress),
+	})
+
+	pom.MarkOffset(100, "modified_meta")
+
+	select {
+	case err := <-pom.Errors():
+		if !errors.Is(err, ErrOffsetsLoadInProgress) {
+			t.Errorf("Expected ErrOffsetsLoadInProgress, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected ErrOffsetsLoadInProgress once the retries are exhausted")
+	}
+
+	pom.AsyncClose()
+	safeClose(t, om)
+	safeClose(t, testClient)
+}
+
 // Test of recovery from abort
 func TestAbortPartitionOffsetManager(t *testing.T) {
 	om, testClient, broker, coordinator := initOffsetManager(t, 0)
@@ -151,6 +151,10 @@ func (r *OffsetRequest) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetRequest) headerVersion() int16 {
 	return 1
 }
@@ -176,6 +180,26 @@ func (r *OffsetRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns the version needed by the leader epochs and the
+// isolation level of the request. Blocks added for v1 and up do not hold the
+// maximum number of offsets v0 needs, so the request is never lowered to v0.
+func (r *OffsetRequest) fieldsVersion() int16 {
+	for _, partitions := range r.blocks {
+		for _, block := range partitions {
+			if block.currentLeaderEpoch != -1 {
+				return 4
+			}
+		}
+	}
+	switch {
+	case r.IsolationLevel == ReadCommitted:
+		return 2
+	case r.Version >= 1:
+		return 1
+	}
+	return 0
+}
+
 func (r *OffsetRequest) SetReplicaID(id int32) {
 	r.replicaID = id
 	r.isReplicaIDSet = true
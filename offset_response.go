@@ -181,6 +181,10 @@ func (r *OffsetResponse) version() int16 {
 	return r.Version
 }
 
+func (r *OffsetResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *OffsetResponse) headerVersion() int16 {
 	return 0
 }
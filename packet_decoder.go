@@ -30,6 +30,7 @@ type packetDecoder interface {
 	getCompactNullableString() (*string, error)
 	getCompactInt32Array() ([]int32, error)
 	getInt32Array() ([]int32, error)
+	getNullableInt32Array() ([]int32, error)
 	getInt64Array() ([]int64, error)
 	getStringArray() ([]string, error)
 
@@ -45,6 +46,9 @@ type packetDecoder interface {
 
 	// To record metrics when provided
 	metricRegistry() metrics.Registry
+
+	// Whether records are decoded with Consumer.LenientDecompression
+	lenientDecompression() bool
 }
 
 // PushDecoder is the interface for decoding fields like CRCs and lengths where the validity
@@ -31,6 +31,7 @@ type packetEncoder interface {
 	putCompactInt32Array(in []int32) error
 	putNullableCompactInt32Array(in []int32) error
 	putInt32Array(in []int32) error
+	putNullableInt32Array(in []int32) error
 	putInt64Array(in []int64) error
 	putEmptyTaggedFieldArray()
 
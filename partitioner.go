@@ -70,6 +70,14 @@ func WithCustomHashFunction(hasher func() hash.Hash32) HashPartitionerOption {
 	}
 }
 
+// WithStickyFallback means that messages with a nil key are not spread randomly but sent to one
+// partition until batchBytes worth of messages were sent to it, see NewStickyPartitioner
+func WithStickyFallback(batchBytes int) HashPartitionerOption {
+	return func(hp *hashPartitioner) {
+		hp.random = newStickyPartitioner(batchBytes)
+	}
+}
+
 // WithCustomFallbackPartitioner lets you specify what HashPartitioner should be used in case a Distribution Key is empty
 func WithCustomFallbackPartitioner(randomHP Partitioner) HashPartitionerOption {
 	return func(hp *hashPartitioner) {
@@ -91,6 +99,17 @@ func (p *manualPartitioner) RequiresConsistency() bool {
 	return true
 }
 
+// isBuiltinAutoPartitioner returns true if p is one of the built-in
+// partitioners choosing the partition on its own. Those never fail, so given a
+// single partition they can only ever pick it.
+func isBuiltinAutoPartitioner(p Partitioner) bool {
+	switch p.(type) {
+	case *randomPartitioner, *roundRobinPartitioner, *hashPartitioner, *stickyPartitioner:
+		return true
+	}
+	return false
+}
+
 type randomPartitioner struct {
 	generator *rand.Rand
 }
@@ -132,6 +151,51 @@ func (p *roundRobinPartitioner) RequiresConsistency() bool {
 	return false
 }
 
+// DefaultStickyBatchBytes is the amount of message bytes NewStickyPartitioner sends to a partition
+// before switching to another one, the default batch.size of the Java producer.
+const DefaultStickyBatchBytes = 16384
+
+type stickyPartitioner struct {
+	generator  *rand.Rand
+	batchBytes int
+	partition  int32
+	bytes      int
+}
+
+func newStickyPartitioner(batchBytes int) *stickyPartitioner {
+	return &stickyPartitioner{
+		generator:  rand.New(rand.NewSource(time.Now().UTC().UnixNano())),
+		batchBytes: batchBytes,
+		partition:  -1,
+	}
+}
+
+func (p *stickyPartitioner) Partition(message *ProducerMessage, numPartitions int32) (int32, error) {
+	if p.partition < 0 || p.partition >= numPartitions || p.bytes >= p.batchBytes {
+		p.partition = p.next(numPartitions)
+		p.bytes = 0
+	}
+	p.bytes += message.ByteSize(2)
+	return p.partition, nil
+}
+
+// next randomly chooses the partition to stick to, a different one than the
+// current partition if possible
+func (p *stickyPartitioner) next(numPartitions int32) int32 {
+	if numPartitions > 1 && p.partition >= 0 && p.partition < numPartitions {
+		next := int32(p.generator.Intn(int(numPartitions - 1)))
+		if next >= p.partition {
+			next++
+		}
+		return next
+	}
+	return int32(p.generator.Intn(int(numPartitions)))
+}
+
+func (p *stickyPartitioner) RequiresConsistency() bool {
+	return false
+}
+
 type hashPartitioner struct {
 	random       Partitioner
 	hasher       hash.Hash32
@@ -206,6 +270,33 @@ func NewConsistentCRCHashPartitioner(topic string) Partitioner {
 	return p
 }
 
+// NewMurmur2HashPartitioner is like NewHashPartitioner except that it uses the murmur2 hash of the
+// encoded bytes of the message key, made positive the same way as the reference Java implementation.
+// Keyed messages thus land on the same partitions as with the default partitioner of the Java producer.
+func NewMurmur2HashPartitioner(topic string) Partitioner {
+	p := new(hashPartitioner)
+	p.random = NewRandomPartitioner(topic)
+	p.hasher = newMurmur2()
+	p.referenceAbs = true
+	p.hashUnsigned = false
+	return p
+}
+
+// NewStickyPartitioner is like NewHashPartitioner except that messages with a nil key are not sent to
+// a random partition each, but to one randomly chosen partition until DefaultStickyBatchBytes worth of
+// messages were sent to it, as the sticky partitioner of the Java producer (KIP-480 and KIP-794) does.
+// Unkeyed messages thus fill up batches instead of being spread over many small ones. Use
+// NewCustomPartitioner with WithStickyFallback to choose how many bytes are sent to each partition,
+// typically Producer.Flush.Bytes.
+func NewStickyPartitioner(topic string) Partitioner {
+	p := new(hashPartitioner)
+	p.random = newStickyPartitioner(DefaultStickyBatchBytes)
+	p.hasher = fnv.New32a()
+	p.referenceAbs = false
+	p.hashUnsigned = false
+	return p
+}
+
 func (p *hashPartitioner) Partition(message *ProducerMessage, numPartitions int32) (int32, error) {
 	if message.Key == nil {
 		return p.random.Partition(message, numPartitions)
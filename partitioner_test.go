@@ -242,6 +242,42 @@ func TestConsistentCRCHashPartitioner(t *testing.T) {
 	}
 }
 
+func TestMurmur2Hash(t *testing.T) {
+	// the expected values are taken from the unit tests of the Java client
+	for key, expected := range map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+	} {
+		hasher := newMurmur2()
+		if _, err := hasher.Write([]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+		if actual := int32(hasher.Sum32()); actual != expected {
+			t.Errorf("murmur2(%q) = %d, expected %d", key, actual, expected)
+		}
+	}
+}
+
+func TestMurmur2HashPartitioner(t *testing.T) {
+	numPartitions := int32(50)
+	partitioner := NewMurmur2HashPartitioner("mytopic")
+
+	// negative hashes are made positive by masking the sign bit, as in the Java client
+	testCases := []partitionerTestCase{
+		{key: "21", expectedPartition: 40},
+		{key: "foobar", expectedPartition: 16},
+		{key: "a-little-bit-long-string", expectedPartition: 12},
+		{key: "lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", expectedPartition: 27},
+	}
+
+	for _, tc := range testCases {
+		partitionAndAssert(t, partitioner, numPartitions, tc)
+	}
+}
+
 func TestCustomPartitionerWithConsistentHashing(t *testing.T) {
 	// Setting both `hashUnsigned` and the hash function to `crc32.NewIEEE` is equivalent to using `NewConsistentCRCHashPartitioner`
 	partitioner := NewCustomPartitioner(
@@ -264,6 +300,61 @@ func TestCustomPartitionerWithConsistentHashing(t *testing.T) {
 	}
 }
 
+func TestStickyPartitioner(t *testing.T) {
+	value := StringEncoder(make([]byte, 1000))
+	msg := &ProducerMessage{Value: value}
+	perBatch := (DefaultStickyBatchBytes + msg.ByteSize(2) - 1) / msg.ByteSize(2)
+	partitioner := NewStickyPartitioner("mytopic")
+
+	previous := int32(-1)
+	for batch := 0; batch < 10; batch++ {
+		first, err := partitioner.Partition(msg, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == previous {
+			t.Errorf("batch %d stuck to partition %d again", batch, first)
+		}
+		for i := 1; i < perBatch; i++ {
+			choice, err := partitioner.Partition(msg, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if choice != first {
+				t.Fatalf("batch %d switched from partition %d to %d after %d messages", batch, first, choice, i)
+			}
+		}
+		previous = first
+	}
+
+	// keyed messages are hashed as with the hash partitioner
+	assertPartitioningConsistent(t, partitioner, &ProducerMessage{Key: StringEncoder("ABC"), Value: value}, 50)
+	if p, ok := partitioner.(DynamicConsistencyPartitioner); !ok || p.MessageRequiresConsistency(msg) {
+		t.Error("expected unkeyed messages not to require consistency")
+	}
+}
+
+func TestStickyPartitionerFewerPartitions(t *testing.T) {
+	partitioner := NewCustomPartitioner(WithStickyFallback(1 << 20))("mytopic")
+	msg := &ProducerMessage{Value: StringEncoder("value")}
+
+	for i := 0; i < 10; i++ {
+		if _, err := partitioner.Partition(msg, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// partitions becoming unavailable must not leave it stuck out of range
+	for i := 0; i < 10; i++ {
+		choice, err := partitioner.Partition(msg, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if choice != 0 {
+			t.Fatalf("expected partition 0 when only one is available, got %d", choice)
+		}
+	}
+}
+
 func TestManualPartitioner(t *testing.T) {
 	partitioner := NewManualPartitioner("mytopic")
 
@@ -395,3 +486,27 @@ func ExamplePartitioner_per_topic() {
 
 	// ...
 }
+
+func TestIsBuiltinAutoPartitioner(t *testing.T) {
+	builtin := []PartitionerConstructor{
+		NewRandomPartitioner,
+		NewRoundRobinPartitioner,
+		NewHashPartitioner,
+		NewReferenceHashPartitioner,
+		NewConsistentCRCHashPartitioner,
+		NewStickyPartitioner,
+		NewCustomHashPartitioner(fnv.New32a),
+	}
+	for _, constructor := range builtin {
+		if p := constructor("mytopic"); !isBuiltinAutoPartitioner(p) {
+			t.Errorf("expected %T to be a built-in partitioner", p)
+		}
+	}
+
+	if p := NewManualPartitioner("mytopic"); isBuiltinAutoPartitioner(p) {
+		t.Error("the manual partitioner must still validate the chosen partition")
+	}
+	if p := make(testPartitioner); isBuiltinAutoPartitioner(p) {
+		t.Error("custom partitioners must always be asked for a partition")
+	}
+}
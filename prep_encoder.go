@@ -170,6 +170,14 @@ func (pe *prepEncoder) putInt32Array(in []int32) error {
 	return nil
 }
 
+func (pe *prepEncoder) putNullableInt32Array(in []int32) error {
+	if in == nil {
+		pe.putInt32(-1)
+		return nil
+	}
+	return pe.putInt32Array(in)
+}
+
 func (pe *prepEncoder) putInt64Array(in []int64) error {
 	err := pe.putArrayLength(len(in))
 	if err != nil {
@@ -28,9 +28,30 @@ type ProduceRequest struct {
 	records         map[string]map[int32]Records
 }
 
-func updateMsgSetMetrics(msgSet *MessageSet, compressionRatioMetric metrics.Histogram,
-	topicCompressionRatioMetric metrics.Histogram,
-) int64 {
+// compressionMetrics groups the histograms tracking how well record batches
+// compress, both for all topics and for a single topic.
+type compressionMetrics struct {
+	ratio, topicRatio               metrics.Histogram
+	uncompressed, topicUncompressed metrics.Histogram
+	compressed, topicCompressed     metrics.Histogram
+}
+
+func (m *compressionMetrics) update(codec CompressionCodec, uncompressedSize, compressedSize int) {
+	// Histogram do not support decimal values, let's multiple it by 100 for better precision
+	intCompressionRatio := int64(float64(uncompressedSize) / float64(compressedSize) * 100)
+	m.ratio.Update(intCompressionRatio)
+	m.topicRatio.Update(intCompressionRatio)
+	// the batch sizes are only meaningful when the batch was actually compressed
+	if codec == CompressionNone {
+		return
+	}
+	m.uncompressed.Update(int64(uncompressedSize))
+	m.topicUncompressed.Update(int64(uncompressedSize))
+	m.compressed.Update(int64(compressedSize))
+	m.topicCompressed.Update(int64(compressedSize))
+}
+
+func updateMsgSetMetrics(msgSet *MessageSet, compression *compressionMetrics) int64 {
 	var topicRecordCount int64
 	for _, messageBlock := range msgSet.Messages {
 		// Is this a fake "message" wrapping real messages?
@@ -42,24 +63,15 @@ func updateMsgSetMetrics(msgSet *MessageSet, compressionRatioMetric metrics.Hist
 		}
 		// Better be safe than sorry when computing the compression ratio
 		if messageBlock.Msg.compressedSize != 0 {
-			compressionRatio := float64(len(messageBlock.Msg.Value)) /
-				float64(messageBlock.Msg.compressedSize)
-			// Histogram do not support decimal values, let's multiple it by 100 for better precision
-			intCompressionRatio := int64(100 * compressionRatio)
-			compressionRatioMetric.Update(intCompressionRatio)
-			topicCompressionRatioMetric.Update(intCompressionRatio)
+			compression.update(messageBlock.Msg.Codec, len(messageBlock.Msg.Value), messageBlock.Msg.compressedSize)
 		}
 	}
 	return topicRecordCount
 }
 
-func updateBatchMetrics(recordBatch *RecordBatch, compressionRatioMetric metrics.Histogram,
-	topicCompressionRatioMetric metrics.Histogram,
-) int64 {
-	if recordBatch.compressedRecords != nil {
-		compressionRatio := int64(float64(recordBatch.recordsLen) / float64(len(recordBatch.compressedRecords)) * 100)
-		compressionRatioMetric.Update(compressionRatio)
-		topicCompressionRatioMetric.Update(compressionRatio)
+func updateBatchMetrics(recordBatch *RecordBatch, compression *compressionMetrics) int64 {
+	if len(recordBatch.compressedRecords) != 0 {
+		compression.update(recordBatch.Codec, recordBatch.recordsLen, len(recordBatch.compressedRecords))
 	}
 
 	return int64(len(recordBatch.Records))
@@ -75,10 +87,12 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 	pe.putInt32(r.Timeout)
 	metricRegistry := pe.metricRegistry()
 	var batchSizeMetric metrics.Histogram
-	var compressionRatioMetric metrics.Histogram
+	var compression compressionMetrics
 	if metricRegistry != nil {
 		batchSizeMetric = getOrRegisterHistogram("batch-size", metricRegistry)
-		compressionRatioMetric = getOrRegisterHistogram("compression-ratio", metricRegistry)
+		compression.ratio = getOrRegisterHistogram("compression-ratio", metricRegistry)
+		compression.uncompressed = getOrRegisterHistogram("uncompressed-batch-size", metricRegistry)
+		compression.compressed = getOrRegisterHistogram("compressed-batch-size", metricRegistry)
 	}
 	totalRecordCount := int64(0)
 
@@ -97,9 +111,10 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 			return err
 		}
 		topicRecordCount := int64(0)
-		var topicCompressionRatioMetric metrics.Histogram
 		if metricRegistry != nil {
-			topicCompressionRatioMetric = getOrRegisterTopicHistogram("compression-ratio", topic, metricRegistry)
+			compression.topicRatio = getOrRegisterTopicHistogram("compression-ratio", topic, metricRegistry)
+			compression.topicUncompressed = getOrRegisterTopicHistogram("uncompressed-batch-size", topic, metricRegistry)
+			compression.topicCompressed = getOrRegisterTopicHistogram("compressed-batch-size", topic, metricRegistry)
 		}
 		for id, records := range partitions {
 			startOffset := pe.offset()
@@ -115,9 +130,9 @@ func (r *ProduceRequest) encode(pe packetEncoder) error {
 			}
 			if metricRegistry != nil {
 				if r.Version >= 3 {
-					topicRecordCount += updateBatchMetrics(records.RecordBatch, compressionRatioMetric, topicCompressionRatioMetric)
+					topicRecordCount += updateBatchMetrics(records.RecordBatch, &compression)
 				} else {
-					topicRecordCount += updateMsgSetMetrics(records.MsgSet, compressionRatioMetric, topicCompressionRatioMetric)
+					topicRecordCount += updateMsgSetMetrics(records.MsgSet, &compression)
 				}
 				batchSize := int64(pe.offset() - startOffset)
 				batchSizeMetric.Update(batchSize)
@@ -208,6 +223,10 @@ func (r *ProduceRequest) version() int16 {
 	return r.Version
 }
 
+func (r *ProduceRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ProduceRequest) headerVersion() int16 {
 	return 1
 }
@@ -237,6 +256,22 @@ func (r *ProduceRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns 3 when the request is transactional or carries record
+// batches, older versions only encode legacy message sets.
+func (r *ProduceRequest) fieldsVersion() int16 {
+	if r.TransactionalID != nil {
+		return 3
+	}
+	for _, partitions := range r.records {
+		for _, records := range partitions {
+			if records.recordsType == defaultRecords {
+				return 3
+			}
+		}
+	}
+	return 0
+}
+
 func (r *ProduceRequest) ensureRecords(topic string, partition int32) {
 	if r.records == nil {
 		r.records = make(map[string]map[int32]Records)
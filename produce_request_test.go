@@ -1,8 +1,11 @@
 package sarama
 
 import (
+	"bytes"
 	"testing"
 	"time"
+
+	"github.com/rcrowley/go-metrics"
 )
 
 var (
@@ -107,3 +110,80 @@ func TestProduceRequest(t *testing.T) {
 	batch.compressedRecords = nil
 	testRequestDecode(t, "one record", request, packet)
 }
+
+func TestProduceRequestLargeEncode(t *testing.T) {
+	const (
+		numRecords = 2048
+		valueSize  = 4096
+	)
+
+	batch := &RecordBatch{
+		Version:         2,
+		LastOffsetDelta: numRecords - 1,
+		FirstTimestamp:  time.Unix(1479847795, 0),
+		MaxTimestamp:    time.Unix(1479847795, 0),
+	}
+	for i := 0; i < numRecords; i++ {
+		value := bytes.Repeat([]byte{byte(i)}, valueSize)
+		batch.addRecord(&Record{OffsetDelta: int64(i), Value: value})
+	}
+
+	request := &ProduceRequest{Version: 3, RequiredAcks: WaitForAll, Timeout: 1000}
+	request.AddBatch("topic", 0, batch)
+
+	packet, err := encode(request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packet) < numRecords*valueSize {
+		t.Fatalf("encoded request is only %d bytes, expected at least %d", len(packet), numRecords*valueSize)
+	}
+
+	decoded := new(ProduceRequest)
+	if err := versionedDecode(packet, decoded, request.Version, nil); err != nil {
+		t.Fatal(err)
+	}
+	records := decoded.records["topic"][0].RecordBatch.Records
+	if len(records) != numRecords {
+		t.Fatalf("expected %d records, decoded %d", numRecords, len(records))
+	}
+	for i, record := range records {
+		if !bytes.Equal(record.Value, batch.Records[i].Value) {
+			t.Fatalf("record %d value mismatch after round trip", i)
+		}
+	}
+}
+
+func TestProduceRequestCompressionMetrics(t *testing.T) {
+	batch := &RecordBatch{
+		Version:          2,
+		Codec:            CompressionGZIP,
+		CompressionLevel: CompressionLevelDefault,
+		LastOffsetDelta:  9,
+		FirstTimestamp:   time.Unix(1479847795, 0),
+		MaxTimestamp:     time.Unix(1479847795, 0),
+	}
+	for i := 0; i < 10; i++ {
+		batch.addRecord(&Record{OffsetDelta: int64(i), Value: bytes.Repeat([]byte("a"), 1024)})
+	}
+
+	request := &ProduceRequest{Version: 3, RequiredAcks: WaitForAll, Timeout: 1000}
+	request.AddBatch("topic", 0, batch)
+
+	registry := metrics.NewRegistry()
+	if _, err := encode(request, registry); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, suffix := range []string{"", "-for-topic-topic"} {
+		uncompressed := registry.Get("uncompressed-batch-size" + suffix).(metrics.Histogram).Snapshot()
+		compressed := registry.Get("compressed-batch-size" + suffix).(metrics.Histogram).Snapshot()
+		if uncompressed.Count() != 1 || compressed.Count() != 1 {
+			t.Fatalf("expected one sample per histogram, got %d and %d", uncompressed.Count(), compressed.Count())
+		}
+		ratio := compressed.Mean() / uncompressed.Mean()
+		if ratio >= 0.1 {
+			t.Errorf("expected a repetitive payload to compress well below 1.0, got ratio %.3f", ratio)
+		}
+	}
+}
@@ -24,7 +24,7 @@ import (
 type ProduceResponseBlock struct {
 	Err         KError    // v0, error_code
 	Offset      int64     // v0, base_offset
-	Timestamp   time.Time // v2, log_append_time, and the broker is configured with `LogAppendTime`
+	Timestamp   time.Time // v2, log_append_time, and the broker is configured with `LogAppendTime`, zero for `CreateTime` (-1)
 	StartOffset int64     // v5, log_start_offset
 }
 
@@ -171,6 +171,10 @@ func (r *ProduceResponse) version() int16 {
 	return r.Version
 }
 
+func (r *ProduceResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *ProduceResponse) headerVersion() int16 {
 	return 0
 }
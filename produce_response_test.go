@@ -112,6 +112,36 @@ func TestProduceResponseDecode(t *testing.T) {
 	}
 }
 
+func TestProduceResponseDecodeCreateTime(t *testing.T) {
+	// with CreateTime the broker returns -1 as log_append_time
+	createTimeV2 := []byte{
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x03, 'f', 'o', 'o',
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x00, 0x00, 0x01, // Partition 1
+		0x00, 0x00, // ErrNoError
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, // Offset 255
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // Timestamp -1 (CreateTime was used)
+
+		0x00, 0x00, 0x00, 0x00, // no throttle time
+	}
+
+	response := ProduceResponse{}
+	testVersionDecodable(t, "create time", &response, createTimeV2, 2)
+	block := response.GetBlock("foo", 1)
+	if block == nil {
+		t.Fatal("Decoding did not produce a block for foo/1")
+	}
+	if !block.Timestamp.IsZero() {
+		t.Error("Expected no log append time for CreateTime, got:", block.Timestamp)
+	}
+
+	// and an absent timestamp is encoded back as -1
+	testEncodable(t, "create time", &response, createTimeV2)
+}
+
 func TestProduceResponseEncode(t *testing.T) {
 	response := ProduceResponse{}
 	response.Blocks = make(map[string]map[int32]*ProduceResponseBlock)
@@ -196,7 +196,7 @@ func TestProduceSetCompressedRequestBuilding(t *testing.T) {
 
 	for _, msgBlock := range req.records["t1"][0].MsgSet.Messages {
 		msg := msgBlock.Msg
-		err := msg.decodeSet()
+		err := msg.decodeSet(false)
 		if err != nil {
 			t.Error("Failed to decode set from payload")
 		}
@@ -0,0 +1,99 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+// requestRateLimiter is a token bucket used to cap the rate at which requests
+// are sent to a broker. It is safe for concurrent use and is waited on before
+// the broker lock is taken, so that a throttled request does not hold up the
+// other users of the broker.
+type requestRateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // maximum number of tokens in the bucket
+	block bool    // whether requests wait for a token, see Net.RateLimit.Block
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+	closed chan none // closed by interrupt to end the waits in progress
+
+	// now and after are overridden in tests
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+func newRequestRateLimiter(requestsPerSecond float64, burst int, block bool) *requestRateLimiter {
+	l := &requestRateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		block:  block,
+		closed: make(chan none),
+		now:    time.Now,
+		after:  time.After,
+	}
+	l.tokens = l.burst
+	l.last = l.now()
+	return l
+}
+
+// l.lock must be held by caller
+func (l *requestRateLimiter) refill() {
+	now := l.now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// allow consumes a token and returns true if one is available right now,
+// otherwise it returns false without consuming anything.
+func (l *requestRateLimiter) allow() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// wait consumes a token, blocking until one becomes available. It returns
+// false, giving the token back, if interrupt is called in the meantime.
+func (l *requestRateLimiter) wait() bool {
+	l.lock.Lock()
+	l.refill()
+	l.tokens--
+	// the token is borrowed from the future, wait until it has been earned
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	closed := l.closed
+	l.lock.Unlock()
+
+	if delay <= 0 {
+		return true
+	}
+	select {
+	case <-l.after(delay):
+		return true
+	case <-closed:
+		l.lock.Lock()
+		l.tokens++
+		l.lock.Unlock()
+		return false
+	}
+}
+
+// interrupt ends the waits in progress, those started afterwards wait as usual.
+func (l *requestRateLimiter) interrupt() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	close(l.closed)
+	l.closed = make(chan none)
+}
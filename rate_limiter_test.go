@@ -0,0 +1,187 @@
+package sarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	current time.Time
+	slept   time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.current
+}
+
+func (c *fakeClock) after(d time.Duration) <-chan time.Time {
+	c.slept += d
+	c.current = c.current.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.current
+	return ch
+}
+
+func newFakeClockRateLimiter(requestsPerSecond float64, burst int) (*requestRateLimiter, *fakeClock) {
+	clock := &fakeClock{current: time.Unix(1700000000, 0)}
+	l := newRequestRateLimiter(requestsPerSecond, burst, true)
+	l.now = clock.now
+	l.after = clock.after
+	l.last = clock.now()
+	return l, clock
+}
+
+func TestRequestRateLimiterPacesRequests(t *testing.T) {
+	l, clock := newFakeClockRateLimiter(10, 1)
+
+	start := clock.now()
+	for i := 0; i < 5; i++ {
+		l.wait()
+	}
+
+	// the first request uses the burst token, each following one waits 100ms
+	if elapsed := clock.now().Sub(start); elapsed != 400*time.Millisecond {
+		t.Errorf("expected 5 requests at 10/s to take 400ms, took %v", elapsed)
+	}
+}
+
+func TestRequestRateLimiterBurst(t *testing.T) {
+	l, clock := newFakeClockRateLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		l.wait()
+	}
+	if clock.slept != 0 {
+		t.Errorf("expected burst of 3 to be sent without waiting, waited %v", clock.slept)
+	}
+
+	l.wait()
+	if clock.slept != 500*time.Millisecond {
+		t.Errorf("expected request after burst to wait 500ms, waited %v", clock.slept)
+	}
+}
+
+func TestRequestRateLimiterAllow(t *testing.T) {
+	l, clock := newFakeClockRateLimiter(4, 1)
+
+	if !l.allow() {
+		t.Fatal("expected first request to be allowed")
+	}
+	if l.allow() {
+		t.Fatal("expected second request to exceed the rate")
+	}
+
+	clock.current = clock.current.Add(250 * time.Millisecond)
+	if !l.allow() {
+		t.Fatal("expected request to be allowed once a token is refilled")
+	}
+	if clock.slept != 0 {
+		t.Errorf("allow should never block, waited %v", clock.slept)
+	}
+}
+
+func TestRequestRateLimiterInterrupt(t *testing.T) {
+	l := newRequestRateLimiter(0.001, 1, true)
+	if !l.wait() {
+		t.Fatal("expected the burst token to be taken")
+	}
+
+	done := make(chan bool)
+	go func() { done <- l.wait() }()
+	time.Sleep(10 * time.Millisecond)
+	l.interrupt()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("expected the interrupted wait to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected interrupt to end the wait")
+	}
+
+	// the token of the interrupted wait was given back
+	if l.tokens < -0.5 || l.tokens >= 1 {
+		t.Errorf("expected the bucket to be empty, got %v tokens", l.tokens)
+	}
+}
+
+func TestBrokerRateLimitWaitsWithoutLock(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	conf.Net.RateLimit.RequestsPerSecond = 0.001
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	throttled := make(chan error)
+	go func() {
+		_, err := broker.GetMetadata(&MetadataRequest{})
+		throttled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// the throttled request does not hold the broker lock
+	if connected, err := broker.Connected(); !connected || err != nil {
+		t.Fatalf("expected the broker to be connected, got %v, %v", connected, err)
+	}
+	if err := broker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-throttled:
+		if !errors.Is(err, ErrNotConnected) {
+			t.Errorf("expected ErrNotConnected, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to end the wait for the rate limit")
+	}
+}
+
+func TestBrokerRateLimitNonBlocking(t *testing.T) {
+	mb := NewMockBroker(t, 0)
+	defer mb.Close()
+	mb.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.ApiVersionsRequest = false
+	conf.Net.RateLimit.RequestsPerSecond = 0.001
+	conf.Net.RateLimit.Block = false
+
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = broker.Close() }()
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrRequestRateLimited) {
+		t.Fatalf("expected ErrRequestRateLimited, got %v", err)
+	}
+
+	// reconnecting does not refill the bucket
+	if err := broker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); !errors.Is(err, ErrRequestRateLimited) {
+		t.Fatalf("expected ErrRequestRateLimited after reconnecting, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package sarama
+
+// rawRequest is a protocolBody carrying an already encoded request body, used
+// by Broker.SendRaw to send hand-crafted requests.
+type rawRequest struct {
+	apiKey     int16
+	apiVersion int16
+	hdrVersion int16
+	body       []byte
+}
+
+func newRawRequest(apiKey, apiVersion int16, body []byte) *rawRequest {
+	r := &rawRequest{
+		apiKey:     apiKey,
+		apiVersion: apiVersion,
+		hdrVersion: 1,
+		body:       body,
+	}
+	// use the header version of a known request so that flexible versions
+	// get their tagged fields, anything else is assumed to be non-flexible
+	if known := allocateBody(apiKey, apiVersion); known != nil {
+		r.hdrVersion = known.headerVersion()
+	}
+	return r
+}
+
+func (r *rawRequest) encode(pe packetEncoder) error {
+	return pe.putRawBytes(r.body)
+}
+
+func (r *rawRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.apiVersion = version
+	r.body, err = pd.getRawBytes(pd.remaining())
+	return err
+}
+
+func (r *rawRequest) key() int16 {
+	return r.apiKey
+}
+
+func (r *rawRequest) version() int16 {
+	return r.apiVersion
+}
+
+// setVersion does nothing, the body is encoded by the caller for the version
+// it asked for.
+func (r *rawRequest) setVersion(v int16) {}
+
+// fieldsVersion keeps the request at the version its body was encoded for.
+func (r *rawRequest) fieldsVersion() int16 {
+	return r.apiVersion
+}
+
+func (r *rawRequest) headerVersion() int16 {
+	return r.hdrVersion
+}
+
+// responseHeaderVersion returns the header version of the response matching
+// this request. Flexible requests are answered with a flexible header, except
+// for ApiVersions whose response header is always v0.
+func (r *rawRequest) responseHeaderVersion() int16 {
+	if r.hdrVersion >= 2 && r.apiKey != 18 { // ApiVersions
+		return 1
+	}
+	return 0
+}
+
+func (r *rawRequest) isValidVersion() bool {
+	return r.apiVersion >= 0
+}
+
+func (r *rawRequest) requiredVersion() KafkaVersion {
+	return MinVersion
+}
@@ -21,6 +21,7 @@ type realDecoder struct {
 	off      int
 	stack    []pushDecoder
 	registry metrics.Registry
+	lenient  bool // see Consumer.LenientDecompression
 }
 
 // primitives
@@ -112,13 +113,23 @@ func (rd *realDecoder) getArrayLength() (int, error) {
 	}
 	tmp := int(int32(binary.BigEndian.Uint32(rd.raw[rd.off:])))
 	rd.off += 4
-	if tmp > rd.remaining() {
+	return rd.checkArrayLength(tmp)
+}
+
+// checkArrayLength validates a decoded array length: -1 denotes a null array,
+// any other negative length is corrupt, and every element takes at least one
+// byte so an array can never be longer than the remaining buffer.
+func (rd *realDecoder) checkArrayLength(n int) (int, error) {
+	if n < -1 {
+		rd.off = len(rd.raw)
+		return -1, errInvalidArrayLength
+	} else if n > rd.remaining() {
 		rd.off = len(rd.raw)
 		return -1, ErrInsufficientData
-	} else if tmp > 2*math.MaxUint16 {
+	} else if n > 2*math.MaxUint16 {
 		return -1, errInvalidArrayLength
 	}
-	return tmp, nil
+	return n, nil
 }
 
 func (rd *realDecoder) getCompactArrayLength() (int, error) {
@@ -130,8 +141,12 @@ func (rd *realDecoder) getCompactArrayLength() (int, error) {
 	if n == 0 {
 		return 0, nil
 	}
+	if n > math.MaxInt32 {
+		rd.off = len(rd.raw)
+		return -1, errInvalidArrayLength
+	}
 
-	return int(n) - 1, nil
+	return rd.checkArrayLength(int(n) - 1)
 }
 
 func (rd *realDecoder) getBool() (bool, error) {
@@ -290,6 +305,10 @@ func (rd *realDecoder) getCompactInt32Array() ([]int32, error) {
 		return nil, nil
 	}
 
+	if n-1 > uint64(rd.remaining()/4) {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
 	arrayLength := int(n) - 1
 
 	ret := make([]int32, arrayLength)
@@ -330,6 +349,29 @@ func (rd *realDecoder) getInt32Array() ([]int32, error) {
 	return ret, nil
 }
 
+// getNullableInt32Array decodes a null array (length -1) as a nil slice and an
+// empty array as an empty, non-nil slice.
+func (rd *realDecoder) getNullableInt32Array() ([]int32, error) {
+	n, err := rd.getArrayLength()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	if rd.remaining() < 4*n {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
+
+	ret := make([]int32, n)
+	for i := range ret {
+		ret[i] = int32(binary.BigEndian.Uint32(rd.raw[rd.off:]))
+		rd.off += 4
+	}
+	return ret, nil
+}
+
 func (rd *realDecoder) getInt64Array() ([]int64, error) {
 	if rd.remaining() < 4 {
 		rd.off = len(rd.raw)
@@ -398,7 +440,7 @@ func (rd *realDecoder) getSubset(length int) (packetDecoder, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &realDecoder{raw: buf}, nil
+	return &realDecoder{raw: buf, lenient: rd.lenient}, nil
 }
 
 func (rd *realDecoder) getRawBytes(length int) ([]byte, error) {
@@ -419,7 +461,7 @@ func (rd *realDecoder) peek(offset, length int) (packetDecoder, error) {
 		return nil, ErrInsufficientData
 	}
 	off := rd.off + offset
-	return &realDecoder{raw: rd.raw[off : off+length]}, nil
+	return &realDecoder{raw: rd.raw[off : off+length], lenient: rd.lenient}, nil
 }
 
 func (rd *realDecoder) peekInt8(offset int) (int8, error) {
@@ -466,3 +508,7 @@ func (rd *realDecoder) pop() error {
 func (rd *realDecoder) metricRegistry() metrics.Registry {
 	return rd.registry
 }
+
+func (rd *realDecoder) lenientDecompression() bool {
+	return rd.lenient
+}
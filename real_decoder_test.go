@@ -0,0 +1,127 @@
+package sarama
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRealDecoderGetArrayLength(t *testing.T) {
+	large := make([]byte, 4+2*65536)
+	binary.BigEndian.PutUint32(large, 2*65536)
+
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected int
+		err      error
+	}{
+		{"zero", []byte{0x00, 0x00, 0x00, 0x00}, 0, nil},
+		{"null", []byte{0xff, 0xff, 0xff, 0xff}, -1, nil},
+		{"negative", []byte{0xff, 0xff, 0xff, 0xfb}, -1, errInvalidArrayLength},
+		{"longer than buffer", []byte{0x00, 0x00, 0x03, 0xe8, 0x01, 0x02}, -1, ErrInsufficientData},
+		{"absurd", large, -1, errInvalidArrayLength},
+		{"truncated", []byte{0x00, 0x00}, -1, ErrInsufficientData},
+	}
+
+	for _, tc := range testCases {
+		rd := realDecoder{raw: tc.raw}
+		n, err := rd.getArrayLength()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.err, err)
+		}
+		if n != tc.expected {
+			t.Errorf("%s: expected length %d, got %d", tc.name, tc.expected, n)
+		}
+	}
+}
+
+func TestRealDecoderGetCompactArrayLength(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected int
+		err      error
+	}{
+		{"null", []byte{0x00}, 0, nil},
+		{"zero", []byte{0x01}, 0, nil},
+		{"two", []byte{0x03, 0x01, 0x02}, 2, nil},
+		{"longer than buffer", []byte{0xe9, 0x07, 0x01}, -1, ErrInsufficientData},
+		{"absurd", []byte{0xff, 0xff, 0xff, 0xff, 0x7f}, -1, errInvalidArrayLength},
+	}
+
+	for _, tc := range testCases {
+		rd := realDecoder{raw: tc.raw}
+		n, err := rd.getCompactArrayLength()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.err, err)
+		}
+		if n != tc.expected {
+			t.Errorf("%s: expected length %d, got %d", tc.name, tc.expected, n)
+		}
+	}
+}
+
+type nullableInt32ArrayHolder struct {
+	values []int32
+}
+
+func (h *nullableInt32ArrayHolder) encode(pe packetEncoder) error {
+	return pe.putNullableInt32Array(h.values)
+}
+
+func (h *nullableInt32ArrayHolder) decode(pd packetDecoder) (err error) {
+	h.values, err = pd.getNullableInt32Array()
+	return err
+}
+
+func TestNullableInt32ArrayRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		values  []int32
+		encoded []byte
+	}{
+		{"null", nil, []byte{0xff, 0xff, 0xff, 0xff}},
+		{"empty", []int32{}, []byte{0x00, 0x00, 0x00, 0x00}},
+		{"populated", []int32{1, -1}, []byte{
+			0x00, 0x00, 0x00, 0x02,
+			0x00, 0x00, 0x00, 0x01,
+			0xff, 0xff, 0xff, 0xff,
+		}},
+	}
+
+	for _, tc := range testCases {
+		buf, err := encode(&nullableInt32ArrayHolder{values: tc.values}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !bytes.Equal(buf, tc.encoded) {
+			t.Errorf("%s: expected encoding %v, got %v", tc.name, tc.encoded, buf)
+		}
+
+		decoded := new(nullableInt32ArrayHolder)
+		if err := decode(buf, decoded, nil); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(decoded.values, tc.values) {
+			t.Errorf("%s: expected %#v after round trip, got %#v", tc.name, tc.values, decoded.values)
+		}
+	}
+}
+
+func TestRealDecoderGetNullableInt32ArrayTruncated(t *testing.T) {
+	rd := realDecoder{raw: []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01}}
+	if _, err := rd.getNullableInt32Array(); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("expected ErrInsufficientData, got %v", err)
+	}
+}
+
+func TestRealDecoderGetCompactInt32ArrayTruncated(t *testing.T) {
+	// claims two elements but only holds one
+	rd := realDecoder{raw: []byte{0x03, 0x00, 0x00, 0x00, 0x01}}
+	if _, err := rd.getCompactInt32Array(); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("expected ErrInsufficientData, got %v", err)
+	}
+}
@@ -178,6 +178,16 @@ func (re *realEncoder) putInt32Array(in []int32) error {
 	return nil
 }
 
+// putNullableInt32Array encodes a nil slice as a null array (length -1),
+// distinct from an empty one.
+func (re *realEncoder) putNullableInt32Array(in []int32) error {
+	if in == nil {
+		re.putInt32(-1)
+		return nil
+	}
+	return re.putInt32Array(in)
+}
+
 func (re *realEncoder) putInt64Array(in []int64) error {
 	err := re.putArrayLength(len(in))
 	if err != nil {
@@ -84,13 +84,21 @@ func (b *RecordBatch) encode(pe packetEncoder) error {
 		return err
 	}
 
-	if b.compressedRecords == nil {
-		if err := b.encodeRecords(pe); err != nil {
+	if b.compressedRecords == nil && b.Codec == CompressionNone && isGathering(pe) {
+		// encode the records in place so that their values are referenced
+		// by the encoder rather than copied into an intermediate buffer
+		if err := recordsArray(b.Records).encode(pe); err != nil {
+			return err
+		}
+	} else {
+		if b.compressedRecords == nil {
+			if err := b.encodeRecords(pe); err != nil {
+				return err
+			}
+		}
+		if err := pe.putRawBytes(b.compressedRecords); err != nil {
 			return err
 		}
-	}
-	if err := pe.putRawBytes(b.compressedRecords); err != nil {
-		return err
 	}
 
 	if err := pe.pop(); err != nil {
@@ -177,10 +185,13 @@ func (b *RecordBatch) decode(pd packetDecoder) (err error) {
 	}
 
 	if err = pd.pop(); err != nil {
+		if errors.Is(err, ErrCRCMismatch) {
+			return CorruptRecordsError{Offset: b.FirstOffset, Err: err}
+		}
 		return err
 	}
 
-	recBuffer, err = decompress(b.Codec, recBuffer)
+	recBuffer, err = decompress(b.Codec, recBuffer, pd.lenientDecompression())
 	if err != nil {
 		return err
 	}
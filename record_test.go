@@ -252,3 +252,39 @@ func TestRecordBatchDecoding(t *testing.T) {
 		}
 	}
 }
+
+func TestRecordBatchLenientDecompression(t *testing.T) {
+	records := []*Record{{Key: []byte{0x01}, Value: []byte("hello lenient decompression")}}
+	raw, err := encode(recordsArray(records), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the attributes claim gzip but the payload is snappy
+	batch := &RecordBatch{
+		Version:        2,
+		Codec:          CompressionGZIP,
+		FirstTimestamp: time.Unix(1479847795, 0),
+		MaxTimestamp:   time.Unix(1479847795, 0),
+		Records:        records,
+	}
+	if batch.compressedRecords, err = compress(CompressionSnappy, CompressionLevelDefault, raw); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := encode(batch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := decode(encoded, &RecordBatch{}, nil); err == nil {
+		t.Fatal("expected strict decoding of a mislabelled batch to fail")
+	}
+
+	decoded := &RecordBatch{}
+	if err := decoded.decode(&realDecoder{raw: encoded, lenient: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Records) != 1 || string(decoded.Records[0].Value) != "hello lenient decompression" {
+		t.Errorf("unexpected records after lenient decoding: %v", decoded.Records)
+	}
+}
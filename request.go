@@ -11,11 +11,21 @@ type protocolBody interface {
 	versionedDecoder
 	key() int16
 	version() int16
+	setVersion(int16)
 	headerVersion() int16
 	isValidVersion() bool
 	requiredVersion() KafkaVersion
 }
 
+// fieldsVersioner is implemented by request bodies which a broker may lower to
+// an older version. A broker refuses to lower such a request below the version
+// its fields need rather than silently dropping them, and never lowers the
+// bodies which do not implement it.
+type fieldsVersioner interface {
+	// fieldsVersion returns the lowest version encoding every field set.
+	fieldsVersion() int16
+}
+
 type request struct {
 	correlationID int32
 	clientID      string
@@ -161,7 +171,8 @@ func allocateBody(key, version int16) protocolBody {
 		return &DeleteRecordsRequest{Version: version}
 	case 22:
 		return &InitProducerIDRequest{Version: version}
-	// 23: OffsetForLeaderEpochRequest
+	case 23:
+		return &OffsetForLeaderEpochRequest{Version: version}
 	case 24:
 		return &AddPartitionsToTxnRequest{Version: version}
 	case 25:
@@ -194,7 +205,8 @@ func allocateBody(key, version int16) protocolBody {
 	// 41: DescribeDelegationTokenRequest
 	case 42:
 		return &DeleteGroupsRequest{Version: version}
-	// 43: ElectLeadersRequest
+	case 43:
+		return &ElectLeadersRequest{Version: version}
 	case 44:
 		return &IncrementalAlterConfigsRequest{Version: version}
 	case 45:
@@ -219,7 +231,8 @@ func allocateBody(key, version int16) protocolBody {
 		// 57: UpdateFeaturesRequest
 		// 58: EnvelopeRequest
 		// 59: FetchSnapshotRequest
-		// 60: DescribeClusterRequest
+	case 60:
+		return &DescribeClusterRequest{Version: version}
 		// 61: DescribeProducersRequest
 		// 62: BrokerRegistrationRequest
 		// 63: BrokerHeartbeatRequest
@@ -128,6 +128,8 @@ func allocateResponseBody(req protocolBody) protocolBody {
 		return &DeleteRecordsResponse{Version: version}
 	case 22:
 		return &InitProducerIDResponse{Version: version}
+	case 23:
+		return &OffsetForLeaderEpochResponse{Version: version}
 	case 24:
 		return &AddPartitionsToTxnResponse{Version: version}
 	case 25:
@@ -154,6 +156,8 @@ func allocateResponseBody(req protocolBody) protocolBody {
 		return &CreatePartitionsResponse{Version: version}
 	case 42:
 		return &DeleteGroupsResponse{Version: version}
+	case 43:
+		return &ElectLeadersResponse{Version: version}
 	case 44:
 		return &IncrementalAlterConfigsResponse{Version: version}
 	case 45:
@@ -170,6 +174,8 @@ func allocateResponseBody(req protocolBody) protocolBody {
 		return &DescribeUserScramCredentialsResponse{Version: version}
 	case 51:
 		return &AlterUserScramCredentialsResponse{Version: version}
+	case 60:
+		return &DescribeClusterResponse{Version: version}
 	}
 	return nil
 }
@@ -64,6 +64,10 @@ Producer related metrics:
 	| records-per-request-for-topic-<topic>     | histogram  | Distribution of the number of records sent per request for a given topic             |
 	| compression-ratio                         | histogram  | Distribution of the compression ratio times 100 of record batches for all topics     |
 	| compression-ratio-for-topic-<topic>       | histogram  | Distribution of the compression ratio times 100 of record batches for a given topic  |
+	| uncompressed-batch-size                   | histogram  | Distribution of the bytes per record batch before compression for all topics         |
+	| uncompressed-batch-size-for-topic-<topic> | histogram  | Distribution of the bytes per record batch before compression for a given topic      |
+	| compressed-batch-size                     | histogram  | Distribution of the bytes per record batch after compression for all topics          |
+	| compressed-batch-size-for-topic-<topic>   | histogram  | Distribution of the bytes per record batch after compression for a given topic       |
 	+-------------------------------------------+------------+--------------------------------------------------------------------------------------+
 
 Consumer related metrics:
@@ -27,6 +27,10 @@ func (r *SaslAuthenticateRequest) version() int16 {
 	return r.Version
 }
 
+func (r *SaslAuthenticateRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SaslAuthenticateRequest) headerVersion() int16 {
 	return 1
 }
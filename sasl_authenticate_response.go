@@ -55,6 +55,10 @@ func (r *SaslAuthenticateResponse) version() int16 {
 	return r.Version
 }
 
+func (r *SaslAuthenticateResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SaslAuthenticateResponse) headerVersion() int16 {
 	return 0
 }
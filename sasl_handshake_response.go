@@ -34,6 +34,10 @@ func (r *SaslHandshakeResponse) version() int16 {
 	return r.Version
 }
 
+func (r *SaslHandshakeResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SaslHandshakeResponse) headerVersion() int16 {
 	return 0
 }
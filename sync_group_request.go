@@ -119,6 +119,10 @@ func (r *SyncGroupRequest) version() int16 {
 	return r.Version
 }
 
+func (r *SyncGroupRequest) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SyncGroupRequest) headerVersion() int16 {
 	return 1
 }
@@ -142,6 +146,14 @@ func (r *SyncGroupRequest) requiredVersion() KafkaVersion {
 	}
 }
 
+// fieldsVersion returns 3 when GroupInstanceId is set for static membership.
+func (r *SyncGroupRequest) fieldsVersion() int16 {
+	if r.GroupInstanceId != nil {
+		return 3
+	}
+	return 0
+}
+
 func (r *SyncGroupRequest) AddGroupAssignment(memberId string, memberAssignment []byte) {
 	r.GroupAssignments = append(r.GroupAssignments, SyncGroupRequestAssignment{
 		MemberId:   memberId,
@@ -55,6 +55,10 @@ func (r *SyncGroupResponse) version() int16 {
 	return r.Version
 }
 
+func (r *SyncGroupResponse) setVersion(v int16) {
+	r.Version = v
+}
+
 func (r *SyncGroupResponse) headerVersion() int16 {
 	return 0
 }
@@ -93,6 +93,10 @@ func (a *TxnOffsetCommitRequest) version() int16 {
 	return a.Version
 }
 
+func (a *TxnOffsetCommitRequest) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *TxnOffsetCommitRequest) headerVersion() int16 {
 	return 1
 }
@@ -80,6 +80,10 @@ func (a *TxnOffsetCommitResponse) version() int16 {
 	return a.Version
 }
 
+func (a *TxnOffsetCommitResponse) setVersion(v int16) {
+	a.Version = v
+}
+
 func (a *TxnOffsetCommitResponse) headerVersion() int16 {
 	return 0
 }
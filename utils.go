@@ -3,8 +3,10 @@ package sarama
 import (
 	"bufio"
 	"fmt"
+	"math/rand"
 	"net"
 	"regexp"
+	"time"
 )
 
 type none struct{}
@@ -54,6 +56,43 @@ func safeAsyncClose(b *Broker) {
 	})
 }
 
+// NewExponentialBackoff returns a backoff function for the Retry.BackoffFunc
+// settings of Metadata, Producer and Producer.Transaction. The first retry
+// waits for initial, and every following one waits twice as long as the one
+// before it, up to maxBackoff. A jitter of 20% either way keeps the clients
+// which lost a broker at the same time from reconnecting in lockstep (KIP-580).
+// See NewConsumerExponentialBackoff for Consumer.Retry.BackoffFunc.
+//
+// There is no separate reconnection state machine: a broker whose connection
+// fails is opened again by the client on its next use, and these retries are
+// what space out the reconnections.
+func NewExponentialBackoff(initial, maxBackoff time.Duration) func(retries, maxRetries int) time.Duration {
+	backoff := NewConsumerExponentialBackoff(initial, maxBackoff)
+	return func(retries, maxRetries int) time.Duration {
+		return backoff(retries)
+	}
+}
+
+// NewConsumerExponentialBackoff is NewExponentialBackoff for the
+// Consumer.Retry.BackoffFunc setting, which is not given the maximum number of
+// retries.
+func NewConsumerExponentialBackoff(initial, maxBackoff time.Duration) func(retries int) time.Duration {
+	if maxBackoff < initial {
+		maxBackoff = initial
+	}
+	return func(retries int) time.Duration {
+		backoff := initial
+		for i := 0; i < retries && backoff < maxBackoff; i++ {
+			backoff *= 2
+		}
+		backoff = time.Duration(float64(backoff) * (0.8 + 0.4*rand.Float64()))
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		return backoff
+	}
+}
+
 // Encoder is a simple interface for any type that can be encoded as an array of bytes
 // in order to be sent as the key or value of a Kafka message. Length() is provided as an
 // optimization, and must return the same as len() on the result of Encode().
@@ -90,7 +129,9 @@ func (b ByteEncoder) Length() int {
 }
 
 // bufConn wraps a net.Conn with a buffer for reads to reduce the number of
-// reads that trigger syscalls.
+// reads that trigger syscalls. Writes are deliberately left unbuffered so that
+// every request reaches the socket as soon as it is sent, without waiting to
+// be coalesced with later requests.
 type bufConn struct {
 	net.Conn
 	buf *bufio.Reader
@@ -1,6 +1,12 @@
 package sarama
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
 
 func TestVersionCompare(t *testing.T) {
 	if V0_8_2_0.IsAtLeast(V0_8_2_1) {
@@ -93,3 +99,67 @@ func TestVersionParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := NewExponentialBackoff(100*time.Millisecond, time.Second)
+	for retries, expected := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			got := backoff(retries, 5)
+			if got < expected*8/10 || got > expected*12/10 || got > time.Second {
+				t.Fatalf("retry %d: expected %s with 20%% jitter, got %s", retries, expected, got)
+			}
+		}
+	}
+	if got := backoff(1000, 1000); got > time.Second || got < 800*time.Millisecond {
+		t.Errorf("expected the backoff to stay below 1s, got %s", got)
+	}
+}
+
+func TestConsumerExponentialBackoff(t *testing.T) {
+	config := NewTestConfig()
+	config.Consumer.Retry.BackoffFunc = NewConsumerExponentialBackoff(100*time.Millisecond, time.Second)
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	backoff := config.Consumer.Retry.BackoffFunc
+	for retries, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if got := backoff(retries); got < expected*8/10 || got > expected*12/10 {
+			t.Errorf("retry %d: expected %s with 20%% jitter, got %s", retries, expected, got)
+		}
+	}
+	if got := backoff(1000); got > time.Second || got < 800*time.Millisecond {
+		t.Errorf("expected the backoff to stay below 1s, got %s", got)
+	}
+}
+
+func TestBufConnWritesAreNotBuffered(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	conn := newBufConn(client)
+	request := []byte{0x00, 0x00, 0x00, 0x02, 0x01, 0x02}
+
+	go func() {
+		_, _ = conn.Write(request)
+	}()
+
+	// net.Pipe has no internal buffering, so the bytes only arrive if the
+	// write went straight to the connection
+	if err := server.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	received := make([]byte, len(request))
+	if _, err := io.ReadFull(server, received); err != nil {
+		t.Fatalf("request was not written through immediately: %v", err)
+	}
+	if !bytes.Equal(received, request) {
+		t.Errorf("expected %v, got %v", request, received)
+	}
+}